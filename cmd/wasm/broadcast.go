@@ -0,0 +1,48 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/git-calendar/core/pkg/api"
+)
+
+// The name of the BroadcastChannel connecting all tabs of the app.
+const changesChannelName = "git-calendar-changes"
+
+// A change notifier telling other browser tabs (of the same origin) which calendar was changed.
+type broadcastNotifier struct {
+	channel js.Value // JS BroadcastChannel (https://developer.mozilla.org/en-US/docs/Web/API/BroadcastChannel)
+}
+
+func (n broadcastNotifier) Notify(calendar string) {
+	n.channel.Call("postMessage", calendar)
+}
+
+// Opens the channel and listens for changes made by other tabs. On every message the changed calendars
+// are reloaded and JS gets notified via onCalendarsChanged(namesJson), if defined.
+// Returns nil if the browser doesn't support BroadcastChannel.
+func listenForChanges(api *api.Api) api.ChangeNotifier {
+	channelClass := js.Global().Get("BroadcastChannel")
+	if channelClass.Type() != js.TypeFunction {
+		return nil
+	}
+	channel := channelClass.New(changesChannelName)
+
+	channel.Set("onmessage", js.FuncOf(func(this js.Value, args []js.Value) any {
+		go func() { // must not block the JS event loop
+			changed, err := api.ReloadChanged()
+			if err != nil {
+				js.Global().Get("console").Call("error", "failed to reload changed calendars", err.Error())
+				return
+			}
+			if callback := js.Global().Get("onCalendarsChanged"); callback.Type() == js.TypeFunction {
+				callback.Invoke(changed)
+			}
+		}()
+		return nil
+	}))
+
+	return broadcastNotifier{channel: channel}
+}
@@ -0,0 +1,34 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+)
+
+// A log sink printing the records to the browser console, using console.debug/info/warn/error according to the level.
+type consoleSink struct{}
+
+func (consoleSink) Log(recordJson string) {
+	var record struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}
+	_ = json.Unmarshal([]byte(recordJson), &record)
+
+	method := "log"
+	switch record.Level {
+	case "DEBUG":
+		method = "debug"
+	case "INFO":
+		method = "info"
+	case "WARN":
+		method = "warn"
+	case "ERROR":
+		method = "error"
+	}
+
+	// the message first, then the whole record as an object (fields are expandable in devtools)
+	js.Global().Get("console").Call(method, record.Msg, js.Global().Get("JSON").Call("parse", recordJson))
+}
@@ -0,0 +1,62 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// A credential provider calling a JS function (calendar, remoteName, remoteUrl) => credentials.
+// The function may be async; it returns an object like {username, password} or {token}, or null for anonymous access.
+type jsCredentialProvider struct {
+	fn js.Value
+}
+
+func (p jsCredentialProvider) Credentials(calendar, remoteName, remoteUrl string) (credsJson string, err error) {
+	defer func() {
+		if r := recover(); r != nil { // the function threw
+			err = fmt.Errorf("credential provider failed: %v", r)
+		}
+	}()
+
+	result, err := await(p.fn.Invoke(calendar, remoteName, remoteUrl))
+	if err != nil {
+		return "", err
+	}
+	if result.IsNull() || result.IsUndefined() {
+		return "", nil
+	}
+	if result.Type() == js.TypeString {
+		return result.String(), nil
+	}
+	return js.Global().Get("JSON").Call("stringify", result).String(), nil
+}
+
+// Waits for the value to resolve, if it's a Promise. Must not be called from the JS event loop (a js.FuncOf callback).
+func await(value js.Value) (js.Value, error) {
+	if value.Type() != js.TypeObject || value.Get("then").Type() != js.TypeFunction {
+		return value, nil
+	}
+
+	results := make(chan js.Value, 1)
+	errs := make(chan error, 1)
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) any {
+		results <- args[0]
+		return nil
+	})
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) any {
+		errs <- js.Error{Value: args[0]}
+		return nil
+	})
+	defer onResolve.Release()
+	defer onReject.Release()
+
+	value.Call("then", onResolve, onReject)
+	select {
+	case result := <-results:
+		return result, nil
+	case err := <-errs:
+		return js.Undefined(), err
+	}
+}
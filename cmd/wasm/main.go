@@ -3,6 +3,8 @@
 package main
 
 import (
+	"errors"
+	"log/slog"
 	"syscall/js"
 
 	"github.com/git-calendar/core/pkg/api"
@@ -11,6 +13,8 @@ import (
 // This is the starting point which gets called from JS.
 func main() {
 	api := api.NewApi()
+	api.SetLogSink(consoleSink{}, int(slog.LevelInfo))
+	api.SetChangeNotifier(listenForChanges(api))
 
 	RegisterCallbacks(api)
 
@@ -30,11 +34,117 @@ func RegisterCallbacks(api *api.Api) {
 					return nil, api.CloneCalendar(args[0].String(), args[1].String())
 				})
 			}),
+			"setCredentialProvider": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					if len(args) == 0 || args[0].Type() != js.TypeFunction {
+						api.SetCredentialProvider(nil)
+					} else {
+						api.SetCredentialProvider(jsCredentialProvider{fn: args[0]})
+					}
+					return nil, nil
+				})
+			}),
+			"addRemote": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.AddRemote(args[0].String(), args[1].String(), args[2].String())
+				})
+			}),
+			"listRemotes": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.ListRemotes(args[0].String())
+				})
+			}),
+			"removeRemote": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.RemoveRemote(args[0].String(), args[1].String())
+				})
+			}),
+			"renameRemote": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.RenameRemote(args[0].String(), args[1].String(), args[2].String())
+				})
+			}),
+			"setRemoteUrl": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.SetRemoteURL(args[0].String(), args[1].String(), args[2].String())
+				})
+			}),
+			"cloneCalendarWithProgress": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.CloneCalendarWithProgress(args[0].String(), args[1].String(), args[2].String(), progressListenerArg(args, 3))
+				})
+			}),
+			"fetch": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.Fetch(args[0].String(), args[1].String(), args[2].String(), progressListenerArg(args, 3))
+				})
+			}),
+			"pull": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.Pull(args[0].String(), args[1].String(), args[2].String(), progressListenerArg(args, 3))
+				})
+			}),
+			"push": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.Push(args[0].String(), args[1].String(), args[2].String(), progressListenerArg(args, 3))
+				})
+			}),
+			"cancel": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					api.Cancel(args[0].String())
+					return nil, nil
+				})
+			}),
 			"removeCalendar": js.FuncOf(func(this js.Value, args []js.Value) any {
 				return wrapPromise(func() (any, error) {
 					return nil, api.RemoveCalendar(args[0].String())
 				})
 			}),
+			"renameCalendar": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.RenameCalendar(args[0].String(), args[1].String())
+				})
+			}),
+			"duplicateCalendar": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.DuplicateCalendar(args[0].String(), args[1].String())
+				})
+			}),
+			"updateCalendarConfig": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.UpdateCalendarConfig(args[0].String(), args[1].String())
+				})
+			}),
+			"listTags": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.ListTags(args[0].String())
+				})
+			}),
+			"createTag": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.CreateTag(args[0].String(), args[1].String())
+				})
+			}),
+			"updateTag": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.UpdateTag(args[0].String(), args[1].String())
+				})
+			}),
+			"removeTag": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.RemoveTag(args[0].String(), args[1].String())
+				})
+			}),
+			"renameTag": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.RenameTag(args[0].String(), args[1].String(), args[2].String())
+				})
+			}),
+			"mergeTags": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.MergeTags(args[0].String(), args[1].String(), args[2].String())
+				})
+			}),
 			"listCalendars": js.FuncOf(func(this js.Value, args []js.Value) any {
 				return wrapPromise(func() (any, error) {
 					return api.ListCalendars()
@@ -47,12 +157,53 @@ func RegisterCallbacks(api *api.Api) {
 			}),
 			"pullAll": js.FuncOf(func(this js.Value, args []js.Value) any {
 				return wrapPromise(func() (any, error) {
-					return nil, api.PullAll()
+					return api.PullAll()
 				})
 			}),
 			"pushAll": js.FuncOf(func(this js.Value, args []js.Value) any {
 				return wrapPromise(func() (any, error) {
-					return nil, api.PushAll()
+					return api.PushAll()
+				})
+			}),
+			"lockCalendar": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.LockCalendar(args[0].String())
+				})
+			}),
+			"unlockCalendar": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.UnlockCalendar(args[0].String(), args[1].String())
+				})
+			}),
+			"isCalendarLocked": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.IsCalendarLocked(args[0].String())
+				})
+			}),
+			"setAutoLock": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					api.SetAutoLock(args[0].Int())
+					return nil, nil
+				})
+			}),
+			"setPrivateMode": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.SetPrivateMode(args[0].String(), args[1].Bool())
+				})
+			}),
+			"setEncryptionMode": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.SetEncryptionMode(args[0].String(), args[1].Int())
+				})
+			}),
+			"setPlaintextFields": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.SetPlaintextFields(args[0].String(), args[1].String())
+				})
+			}),
+			"reloadChanged": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.ReloadChanged()
 				})
 			}),
 			"setCorsProxy": js.FuncOf(func(this js.Value, args []js.Value) any {
@@ -80,6 +231,11 @@ func RegisterCallbacks(api *api.Api) {
 					return api.GetEvents(args[0].String(), args[1].String())
 				})
 			}),
+			"getEventsByCategories": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.GetEventsByCategories(args[0].String(), args[1].String(), args[2].String(), args[3].Bool())
+				})
+			}),
 			"updateEvent": js.FuncOf(func(this js.Value, args []js.Value) any {
 				return wrapPromise(func() (any, error) {
 					return api.UpdateEvent(args[0].String())
@@ -95,6 +251,41 @@ func RegisterCallbacks(api *api.Api) {
 					return nil, api.RemoveRepeatingEvent(args[0].String(), args[1].Int())
 				})
 			}),
+			"moveEvent": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.MoveEvent(args[0].String(), args[1].String())
+				})
+			}),
+			"applyBatch": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.ApplyBatch(args[0].String())
+				})
+			}),
+			"listQuarantine": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.ListQuarantine()
+				})
+			}),
+			"removeQuarantined": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return nil, api.RemoveQuarantined(args[0].String(), args[1].String())
+				})
+			}),
+			"resolveQuarantined": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.ResolveQuarantined(args[0].String(), args[1].String(), args[2].String())
+				})
+			}),
+			"checkIntegrity": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.CheckIntegrity()
+				})
+			}),
+			"repair": js.FuncOf(func(this js.Value, args []js.Value) any {
+				return wrapPromise(func() (any, error) {
+					return api.Repair()
+				})
+			}),
 			// TODO others
 		}),
 	)
@@ -113,9 +304,17 @@ func wrapPromise(fn func() (any, error)) any {
 
 		go func() {
 			res, err := fn()
-			if err != nil { // create a JS new Error(message) and invoke it ("throw" it or whatever)
-				// get the error text (default behavior)
+			if err != nil { // create a JS new Error(message) with a code and invoke it ("throw" it or whatever)
+				// get the error text and code (default behavior)
 				errorMessage := err.Error()
+				errorCode := string(api.CodeInternal)
+
+				// errors of the api have a stable code; use their plain message instead of the JSON
+				var apiErr *api.Error
+				if errors.As(err, &apiErr) {
+					errorMessage = apiErr.Message
+					errorCode = string(apiErr.Code)
+				}
 
 				// check if the error is a wrapper for a JS value; if it is, try to get the "message" property and use it instead
 				if jsErr, ok := err.(js.Error); ok {
@@ -125,9 +324,11 @@ func wrapPromise(fn func() (any, error)) any {
 					}
 				}
 
-				// create the JS Error object
+				// create the JS Error object, e.g. {message: "event '...': not found", code: "not_found"}
 				errorConstructor := js.Global().Get("Error")
-				reject.Invoke(errorConstructor.New(errorMessage))
+				jsError := errorConstructor.New(errorMessage)
+				jsError.Set("code", errorCode)
+				reject.Invoke(jsError)
 			} else { // no error, pass the result
 				resolve.Invoke(js.ValueOf(res))
 			}
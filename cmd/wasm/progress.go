@@ -0,0 +1,26 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/git-calendar/core/pkg/api"
+)
+
+// A progress listener calling a JS function with the progress JSON.
+type jsProgressListener struct {
+	fn js.Value
+}
+
+func (l jsProgressListener) OnProgress(progressJson string) {
+	l.fn.Invoke(progressJson)
+}
+
+// Returns the listener calling the i-th argument, or nil if it isn't a function (it's optional).
+func progressListenerArg(args []js.Value, i int) api.ProgressListener {
+	if len(args) <= i || args[i].Type() != js.TypeFunction {
+		return nil
+	}
+	return jsProgressListener{fn: args[i]}
+}
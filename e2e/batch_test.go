@@ -0,0 +1,191 @@
+package e2e
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/git-calendar/core/pkg/filesystem"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/uuid"
+)
+
+func TestBatch_CommitsOnce(t *testing.T) {
+	const calendarName = "test-batch"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	existing, err := c.CreateEvent(core.Event{
+		Calendar: calendarName,
+		Title:    "To Be Removed",
+		From:     date,
+		To:       date.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	repoPath := repoPathOf(t, calendarName)
+	commitsBefore := countCommits(t, repoPath)
+
+	const count = 10
+	created := make([]*core.Event, 0, count)
+	err = c.Batch(func(tx *core.Tx) error {
+		for i := range count {
+			from := date.AddDate(0, 0, i+1)
+			event, err := tx.CreateEvent(core.Event{
+				Id:       uuid.New(),
+				Calendar: calendarName,
+				Title:    "Imported Event",
+				From:     from,
+				To:       from.Add(time.Hour),
+			})
+			if err != nil {
+				return err
+			}
+			created = append(created, event)
+		}
+
+		updated := *created[0]
+		updated.Categories = []string{"imported"}
+		if _, err := tx.UpdateEvent(updated); err != nil {
+			return err
+		}
+		return tx.RemoveEvent(*existing)
+	})
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+
+	if got := countCommits(t, repoPath); got != commitsBefore+1 {
+		t.Errorf("expected exactly one new commit, got %d", got-commitsBefore)
+	}
+
+	events := eventsOfCalendar(c.GetEvents(date, date.AddDate(0, 0, count+1)), calendarName)
+	if len(events) != count {
+		t.Errorf("expected %d events after batch, got %d", count, len(events))
+	}
+	first, err := c.GetEvent(created[0].Id)
+	if err != nil || !slices.Equal(first.Categories, []string{"imported"}) {
+		t.Errorf("update inside batch was not applied: %+v (err: %v)", first, err)
+	}
+	if _, err := c.GetEvent(existing.Id); err == nil {
+		t.Errorf("removed event still exists")
+	}
+}
+
+func TestBatch_RollsBackOnError(t *testing.T) {
+	const calendarName = "test-batch-rollback"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	existing, err := c.CreateEvent(core.Event{
+		Calendar: calendarName,
+		Title:    "Keep Me",
+		From:     date,
+		To:       date.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	repoPath := repoPathOf(t, calendarName)
+	commitsBefore := countCommits(t, repoPath)
+
+	var createdId uuid.UUID
+	errAbort := errors.New("abort")
+	err = c.Batch(func(tx *core.Tx) error {
+		event, err := tx.CreateEvent(core.Event{
+			Id:       uuid.New(),
+			Calendar: calendarName,
+			Title:    "Never Committed",
+			From:     date,
+			To:       date.Add(time.Hour),
+		})
+		if err != nil {
+			return err
+		}
+		createdId = event.Id
+
+		if err := tx.RemoveEvent(*existing); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the batch to fail with errAbort, got: %v", err)
+	}
+
+	if got := countCommits(t, repoPath); got != commitsBefore {
+		t.Errorf("expected no new commits, got %d", got-commitsBefore)
+	}
+	if _, err := c.GetEvent(createdId); err == nil {
+		t.Errorf("event created in a failed batch still exists")
+	}
+	if _, err := c.GetEvent(existing.Id); err != nil {
+		t.Errorf("event removed in a failed batch is gone: %v", err)
+	}
+
+	// the worktree is clean again
+	repo, err := gogit.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	status, err := w.Status()
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	for file, s := range status {
+		if strings.HasPrefix(file, core.EventsDirName) && (s.Staging != gogit.Unmodified || s.Worktree != gogit.Unmodified) {
+			t.Errorf("file %s left modified after rollback: %c%c", file, s.Staging, s.Worktree)
+		}
+	}
+}
+
+// Helper
+func repoPathOf(t *testing.T, calendarName string) string {
+	t.Helper()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("failed to get home dir: %v", err)
+	}
+	return filepath.Join(home, filesystem.DirName, calendarName)
+}
+
+// Helper
+func countCommits(t *testing.T, repoPath string) int {
+	t.Helper()
+	repo, err := gogit.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	iter, err := repo.Log(&gogit.LogOptions{})
+	if err != nil {
+		t.Fatalf("failed to get log: %v", err)
+	}
+	n := 0
+	_ = iter.ForEach(func(*object.Commit) error { n++; return nil })
+	return n
+}
@@ -0,0 +1,142 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestGetEventsByCategories_AnyOrAll(t *testing.T) {
+	const calendarName = "test-categories"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	date := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	events := map[string][]string{
+		"Both":     {"project-x", "billable"},
+		"Project":  {"project-x"},
+		"Billable": {"billable"},
+		"None":     nil,
+	}
+	for title, categories := range events {
+		event := core.Event{Calendar: calendarName, Title: title, Categories: categories, From: date, To: date.Add(time.Hour)}
+		if _, err := c.CreateEvent(event); err != nil {
+			t.Fatalf("failed to create an event: %v", err)
+		}
+	}
+	weekly := core.Event{
+		Calendar: calendarName, Title: "Weekly", Categories: []string{"billable"}, From: date, To: date.Add(time.Hour),
+		Repeat: &core.Repetition{Frequency: core.Week, Interval: 1, Count: 2},
+	}
+	if _, err := c.CreateEvent(weekly); err != nil {
+		t.Fatalf("failed to create a repeating event: %v", err)
+	}
+
+	titles := func(matchAll bool, categories ...string) map[string]int {
+		found := map[string]int{}
+		for _, e := range eventsOfCalendar(c.GetEventsByCategories(date, date.AddDate(0, 1, 0), categories, matchAll), calendarName) {
+			found[e.Title]++
+		}
+		return found
+	}
+
+	if got, want := titles(false, "project-x", "billable"), map[string]int{"Both": 1, "Project": 1, "Billable": 1, "Weekly": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("any of the categories: expected %v, got %v", want, got)
+	}
+	if got, want := titles(true, "project-x", "billable"), map[string]int{"Both": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("all of the categories: expected %v, got %v", want, got)
+	}
+	if got := titles(false); len(got) != 5 {
+		t.Errorf("no categories should match all events, got %v", got)
+	}
+}
+
+func TestLoadCalendars_LegacyTagBecomesCategory(t *testing.T) {
+	const calendarName = "test-categories-legacy"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	// an event file written before the categories
+	id := uuid.New()
+	legacy := `{"title": "Old", "from": "2026-03-01T09:00:00Z", "to": "2026-03-01T10:00:00Z", "calendar": "` + calendarName + `", "tag": "billable"}`
+	path := filepath.Join(repoPathOf(t, calendarName), core.EventsDirName, id.String()+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create events dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("failed to write event file: %v", err)
+	}
+
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	event, err := c2.GetEvent(id)
+	if err != nil {
+		t.Fatalf("legacy event not loaded: %v", err)
+	}
+	if !reflect.DeepEqual(event.Categories, []string{"billable"}) {
+		t.Errorf("expected the tag as the only category, got %v", event.Categories)
+	}
+}
+
+func TestGetEvent_ReturnsCopies(t *testing.T) {
+	const calendarName = "copies"
+	c := newIsolatedCore(t)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	date := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	parent, err := c.CreateEvent(core.Event{
+		Calendar: calendarName, Title: "Weekly", Categories: []string{"billable", "project-x"}, From: date, To: date.Add(time.Hour),
+		Repeat: &core.Repetition{Frequency: core.Week, Interval: 1, Count: 2},
+	})
+	if err != nil {
+		t.Fatalf("failed to create a repeating event: %v", err)
+	}
+
+	change := func(e *core.Event) {
+		e.Repeat.Count = 100
+		e.Repeat.Exceptions = append(e.Repeat.Exceptions, uuid.New())
+		e.Categories[0] = "changed"
+	}
+	got, err := c.GetEvent(parent.Id)
+	if err != nil {
+		t.Fatalf("failed to get the event: %v", err)
+	}
+	change(got)
+	if err := c.Batch(func(tx *core.Tx) error {
+		got, err := tx.GetEvent(parent.Id)
+		if err == nil {
+			change(got)
+		}
+		return err
+	}); err != nil {
+		t.Fatalf("failed to get the event in a batch: %v", err)
+	}
+	for _, occurrence := range c.GetEvents(date, date.AddDate(0, 1, 0)) {
+		change(&occurrence)
+	}
+
+	stored, err := c.GetEvent(parent.Id)
+	if err != nil {
+		t.Fatalf("failed to get the event: %v", err)
+	}
+	if stored.Repeat.Count != 2 || len(stored.Repeat.Exceptions) != 0 || !reflect.DeepEqual(stored.Categories, []string{"billable", "project-x"}) {
+		t.Errorf("the stored event changed through a returned one: %+v %+v", stored, *stored.Repeat)
+	}
+	if n := len(c.GetEvents(date, date.AddDate(0, 1, 0))); n != 2 {
+		t.Errorf("expected 2 occurrences, got %d", n)
+	}
+}
@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/api"
+	"github.com/git-calendar/core/pkg/core"
+)
+
+func TestApi_ConcurrentReadsAndWrites(t *testing.T) {
+	a, err := api.NewApiWithOptions(t.TempDir(), "", "", "")
+	if err != nil {
+		t.Fatalf("failed to create api: %v", err)
+	}
+	for _, name := range []string{"work", "home"} {
+		if err := a.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+
+	const writers = 8
+	const eventsPerWriter = 5
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	from := date.Format(time.RFC3339)
+	to := date.AddDate(0, 0, writers*eventsPerWriter+1).Format(time.RFC3339)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*eventsPerWriter*2)
+
+	for w := range writers {
+		wg.Go(func() {
+			calendar := []string{"work", "home"}[w%2]
+			for i := range eventsPerWriter {
+				start := date.AddDate(0, 0, w*eventsPerWriter+i)
+				eventJson := fmt.Sprintf(`{"title": "Event %d-%d", "calendar": "%s", "from": "%s", "to": "%s"}`,
+					w, i, calendar, start.Format(time.RFC3339), start.Add(time.Hour).Format(time.RFC3339))
+
+				createdJson, err := a.CreateEvent(eventJson)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				var created core.Event
+				if err := json.Unmarshal([]byte(createdJson), &created); err != nil {
+					errs <- err
+					continue
+				}
+
+				created.Title += " (updated)"
+				updatedJson, _ := json.Marshal(created)
+				if _, err := a.UpdateEvent(string(updatedJson)); err != nil {
+					errs <- err
+				}
+			}
+		})
+	}
+	for range writers {
+		wg.Go(func() {
+			for range eventsPerWriter * 2 {
+				if _, err := a.GetEvents(from, to); err != nil {
+					errs <- err
+				}
+				if _, err := a.ListCalendars(); err != nil {
+					errs <- err
+				}
+				if _, err := a.ListQuarantine(); err != nil {
+					errs <- err
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent call failed: %v", err)
+	}
+
+	eventsJson, err := a.GetEvents(from, to)
+	if err != nil {
+		t.Fatalf("failed to get events: %v", err)
+	}
+	var events []core.Event
+	if err := json.Unmarshal([]byte(eventsJson), &events); err != nil {
+		t.Fatalf("failed to unmarshal events: %v", err)
+	}
+	if len(events) != writers*eventsPerWriter {
+		t.Errorf("expected %d events, got %d", writers*eventsPerWriter, len(events))
+	}
+
+	// everything is committed consistently
+	report, err := a.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("failed to check integrity: %v", err)
+	}
+	var parsed core.IntegrityReport
+	if err := json.Unmarshal([]byte(report), &parsed); err != nil || !parsed.IsClean() {
+		t.Errorf("expected a clean repo after concurrent writes, got %s (err: %v)", report, err)
+	}
+}
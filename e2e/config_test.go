@@ -0,0 +1,114 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/git-calendar/core/pkg/core"
+)
+
+func TestUpdateCalendarConfig_EncryptedAndReloaded(t *testing.T) {
+	const calendarName, password = "test-config", "somepassword"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, password); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	cfg := core.CalendarConfig{
+		DisplayName:     "Work Stuff",
+		Color:           "#3366ff",
+		Description:     "Meetings and deadlines",
+		Timezone:        "Europe/Prague",
+		DefaultReminder: 15,
+		Tags:            []core.Tag{{Name: "meeting"}, {Name: "deadline", Color: "#f00"}},
+	}
+	commits := countCommits(t, repoPathOf(t, calendarName)) // the password check
+	if err := c.UpdateCalendarConfig(calendarName, cfg); err != nil {
+		t.Fatalf("failed to update config: %v", err)
+	}
+	if n := countCommits(t, repoPathOf(t, calendarName)); n != commits+1 {
+		t.Errorf("expected 1 commit, got %d", n-commits)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(repoPathOf(t, calendarName), core.ConfigFileName))
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	if strings.Contains(string(raw), "Work Stuff") {
+		t.Errorf("config of an encrypted calendar is stored in plaintext: %s", raw)
+	}
+
+	// reloaded from disk
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	info := calendarInfoOf(t, c2, calendarName)
+	if !reflect.DeepEqual(info.Config, cfg) || !info.Encrypted || info.Locked {
+		t.Errorf("unexpected calendar after reload: %+v", info)
+	}
+
+	// hidden while locked
+	if err := c2.LockCalendar(calendarName); err != nil {
+		t.Fatalf("failed to lock calendar: %v", err)
+	}
+	if info := calendarInfoOf(t, c2, calendarName); !reflect.DeepEqual(info.Config, core.CalendarConfig{}) || !info.Locked {
+		t.Errorf("config of a locked calendar should be empty: %+v", info)
+	}
+	if err := c2.UnlockCalendar(calendarName, password); err != nil {
+		t.Fatalf("failed to unlock calendar: %v", err)
+	}
+	if info := calendarInfoOf(t, c2, calendarName); !reflect.DeepEqual(info.Config, cfg) {
+		t.Errorf("config not restored after unlock: %+v", info.Config)
+	}
+}
+
+func TestUpdateCalendarConfig_Invalid(t *testing.T) {
+	const calendarName = "test-config-invalid"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	for _, cfg := range []core.CalendarConfig{
+		{Color: "blue"},
+		{Timezone: "Mars/Olympus_Mons"},
+		{DefaultReminder: -5},
+		{Tags: []core.Tag{{Name: "work"}, {Name: "work"}}},
+		{Tags: []core.Tag{{Name: " "}}},
+		{Tags: []core.Tag{{Name: "work", Color: "red"}}},
+	} {
+		if err := c.UpdateCalendarConfig(calendarName, cfg); err == nil {
+			t.Errorf("expected an error for config %+v", cfg)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repoPathOf(t, calendarName), core.ConfigFileName)); !os.IsNotExist(err) {
+		t.Errorf("invalid config got written: %v", err)
+	}
+}
+
+// Helper
+func calendarNames(calendars []core.CalendarInfo) []string {
+	names := make([]string, 0, len(calendars))
+	for _, cal := range calendars {
+		names = append(names, cal.Name)
+	}
+	return names
+}
+
+// Helper
+func calendarInfoOf(t *testing.T, c *core.Core, name string) core.CalendarInfo {
+	t.Helper()
+	for _, cal := range c.ListCalendars() {
+		if cal.Name == name {
+			return cal
+		}
+	}
+	t.Fatalf("calendar '%s' not listed", name)
+	return core.CalendarInfo{}
+}
@@ -2,14 +2,18 @@ package e2e
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/git-calendar/core/pkg/core"
+	"github.com/git-calendar/core/pkg/encryption"
 	"github.com/git-calendar/core/pkg/filesystem"
+	gogit "github.com/go-git/go-git/v5"
 	"github.com/google/uuid"
 	aessiv "github.com/jedisct1/go-aes-siv"
 )
@@ -82,3 +86,355 @@ func TestCreateCalendarWithPasswordAndCreateEvent_CreatesJsonFile(t *testing.T)
 		t.Errorf("title is not encrypted: \nin:   %s\nfile: %s", title, parsedEvent.Title)
 	}
 }
+
+func TestSetEncryptionMode_Randomized(t *testing.T) {
+	const calendarName = "test-randomized"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "somepassword")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	err = c.SetEncryptionMode(calendarName, encryption.Randomized)
+	if err != nil {
+		t.Fatalf("failed to set encryption mode: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	eventIn := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Foo Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+	}
+
+	readTitle := func() string {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			t.Fatalf("failed to get home dir: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(home, filesystem.DirName, calendarName, core.EventsDirName, fmt.Sprintf("%s.json", eventIn.Id)))
+		if err != nil {
+			t.Fatalf("failed to read event json file: %v", err)
+		}
+		var parsedEvent struct {
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(b, &parsedEvent); err != nil {
+			t.Fatalf("failed to parse event json file: %v", err)
+		}
+		return parsedEvent.Title
+	}
+
+	if _, err = c.CreateEvent(eventIn); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	first := readTitle()
+
+	if _, err = c.UpdateEvent(eventIn); err != nil { // same values again
+		t.Fatalf("failed to update an event: %v", err)
+	}
+	second := readTitle()
+
+	if first == second {
+		t.Errorf("same title produced the same ciphertext in randomized mode: %s", first)
+	}
+
+	// a fresh core has to pick the mode from the repo metadata
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	eventOut, err := c2.GetEvent(eventIn.Id)
+	if err != nil {
+		t.Fatalf("failed to get an event by id: %v", err)
+	}
+	if eventOut.Title != eventIn.Title {
+		t.Errorf("title mismatch after reload: got %q, want %q", eventOut.Title, eventIn.Title)
+	}
+}
+
+func TestSetPlaintextFields_KeepsTimesReadable(t *testing.T) {
+	const calendarName = "test-plaintext-fields"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "somepassword")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	if err := c.SetPlaintextFields(calendarName, []string{"foo"}); err == nil {
+		t.Errorf("expected an error for unknown field")
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	eventIn := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Foo Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+	}
+	if _, err = c.CreateEvent(eventIn); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	// existing events get rewritten
+	if err := c.SetPlaintextFields(calendarName, []string{"from", "to"}); err != nil {
+		t.Fatalf("failed to set plaintext fields: %v", err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("failed to get home dir: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(home, filesystem.DirName, calendarName, core.EventsDirName, fmt.Sprintf("%s.json", eventIn.Id)))
+	if err != nil {
+		t.Fatalf("failed to read event json file: %v", err)
+	}
+
+	var parsedEvent struct {
+		Title string    `json:"title"`
+		From  time.Time `json:"from"`
+		To    time.Time `json:"to"`
+	}
+	if err := json.Unmarshal(b, &parsedEvent); err != nil {
+		t.Fatalf("failed to parse event json file: %v", err)
+	}
+	if parsedEvent.Title == eventIn.Title {
+		t.Errorf("title is not encrypted: %s", parsedEvent.Title)
+	}
+	if !parsedEvent.From.Equal(eventIn.From) || !parsedEvent.To.Equal(eventIn.To) {
+		t.Errorf("from/to are not in plaintext: %s", b)
+	}
+
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	eventOut, err := c2.GetEvent(eventIn.Id)
+	if err != nil {
+		t.Fatalf("failed to get an event by id: %v", err)
+	}
+	if eventOut.Title != eventIn.Title || !eventOut.From.Equal(eventIn.From) {
+		t.Errorf("events are not the same after reload: \nin:  %+v\n!=\nout: %+v", eventIn, *eventOut)
+	}
+}
+
+func TestSetPrivateMode_HidesCommitMessagesAndExceptions(t *testing.T) {
+	const calendarName = "test-private"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "somepassword")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := c.SetPlaintextFields(calendarName, []string{"from", "to", "repeat"}); err != nil {
+		t.Fatalf("failed to set plaintext fields: %v", err)
+	}
+	if err := c.SetPrivateMode(calendarName, true); err != nil {
+		t.Fatalf("failed to set private mode: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Repeating Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+		Repeat: &core.Repetition{
+			Frequency: core.Day,
+			Interval:  1,
+			Count:     5,
+		},
+	}
+	if _, err = c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	children := c.GetEvents(date, date.AddDate(0, 0, 5))
+	var child core.Event
+	for _, e := range children {
+		if e.ParentId == parent.Id {
+			child = e
+			break
+		}
+	}
+	if err := c.RemoveRepeatingEvent(child, core.Current); err != nil {
+		t.Fatalf("failed to remove child event: %v", err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("failed to get home dir: %v", err)
+	}
+	repoPath := filepath.Join(home, filesystem.DirName, calendarName)
+
+	// exceptions are encrypted even though repeat is a plaintext field
+	b, err := os.ReadFile(filepath.Join(repoPath, core.EventsDirName, fmt.Sprintf("%s.json", parent.Id)))
+	if err != nil {
+		t.Fatalf("failed to read event json file: %v", err)
+	}
+	var parsedEvent struct {
+		Repeat struct {
+			Count      int      `json:"count"`
+			Exceptions []string `json:"exceptions"`
+		} `json:"repeat"`
+	}
+	if err := json.Unmarshal(b, &parsedEvent); err != nil {
+		t.Fatalf("failed to parse event json file: %v", err)
+	}
+	if parsedEvent.Repeat.Count != 5 {
+		t.Errorf("repeat count should be in plaintext: %s", b)
+	}
+	if len(parsedEvent.Repeat.Exceptions) != 1 || parsedEvent.Repeat.Exceptions[0] == child.Id.String() {
+		t.Errorf("exceptions are not encrypted: %v", parsedEvent.Repeat.Exceptions)
+	}
+
+	// commit messages are generic
+	repo, err := gogit.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get head: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get head commit: %v", err)
+	}
+	for range 3 { // the last 3 commits were made in private mode
+		if commit.Message != core.PrivateCommitMessage {
+			t.Errorf("commit message leaks information: %q", commit.Message)
+		}
+		if commit, err = commit.Parent(0); err != nil {
+			t.Fatalf("failed to get parent commit: %v", err)
+		}
+	}
+
+	// reload and check the exception is still there
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	eventOut, err := c2.GetEvent(parent.Id)
+	if err != nil {
+		t.Fatalf("failed to get an event by id: %v", err)
+	}
+	if eventOut.Repeat == nil || len(eventOut.Repeat.Exceptions) != 1 || eventOut.Repeat.Exceptions[0] != child.Id {
+		t.Errorf("exceptions mismatch after reload: %+v", eventOut.Repeat)
+	}
+}
+
+func TestSetEncryptionMode_RefusedWithQuarantinedFiles(t *testing.T) {
+	const calendarName = "test-reencrypt-quarantine"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, "somepassword"); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := c.CreateEvent(core.Event{Calendar: calendarName, Title: "Loaded", From: date, To: date.Add(time.Hour)}); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	// a file the current key can't read
+	garbagePath := fmt.Sprintf("%s/%s.json", core.EventsDirName, uuid.New())
+	if err := os.WriteFile(filepath.Join(repoPathOf(t, calendarName), garbagePath), []byte(`{"title": "Gar`), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := c.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+
+	if err := c.SetEncryptionMode(calendarName, encryption.Randomized); !errors.Is(err, core.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent while a file is quarantined, got %v", err)
+	}
+	if err := c.SetPlaintextFields(calendarName, []string{"from"}); !errors.Is(err, core.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent while a file is quarantined, got %v", err)
+	}
+
+	if err := c.RemoveQuarantined(calendarName, garbagePath); err != nil {
+		t.Fatalf("failed to remove quarantined file: %v", err)
+	}
+	if err := c.SetEncryptionMode(calendarName, encryption.Randomized); err != nil {
+		t.Errorf("failed to set encryption mode without quarantined files: %v", err)
+	}
+}
+
+func TestSetPrivateMode_FileNamesDontRevealTimes(t *testing.T) {
+	const calendarName = "test-private-names"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	if err := c.CreateCalendar(calendarName, "somepassword"); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := c.SetPrivateMode(calendarName, true); err != nil {
+		t.Fatalf("failed to set private mode: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Repeating Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+		Repeat:   &core.Repetition{Frequency: core.Day, Interval: 1, Count: 5},
+	}
+	if _, err := c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	childAt := func(day int) core.Event {
+		for _, e := range c.GetEvents(date.AddDate(0, 0, day), date.AddDate(0, 0, day+1)) {
+			if e.ParentId == parent.Id {
+				return e
+			}
+		}
+		t.Fatalf("no child event on day %d", day)
+		return core.Event{}
+	}
+
+	// detach one child, remove another and split the series
+	child := childAt(1)
+	updated := child
+	updated.Title = "Detached"
+	if _, err := c.UpdateRepeatingEvent(child, updated, core.Current); err != nil {
+		t.Fatalf("failed to detach a child: %v", err)
+	}
+	if err := c.RemoveRepeatingEvent(childAt(2), core.Current); err != nil {
+		t.Fatalf("failed to remove a child: %v", err)
+	}
+	child = childAt(3)
+	updated = child
+	updated.Title = "Split"
+	if _, err := c.UpdateRepeatingEvent(child, updated, core.Following); err != nil {
+		t.Fatalf("failed to split the series: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(repoPathOf(t, calendarName), core.EventsDirName))
+	if err != nil {
+		t.Fatalf("failed to list event files: %v", err)
+	}
+	if len(entries) < 3 {
+		t.Fatalf("expected at least 3 event files, got: %v", entries)
+	}
+	for _, entry := range entries {
+		id, err := uuid.Parse(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			t.Errorf("unexpected file %q", entry.Name())
+			continue
+		}
+		if id.Version() == 8 { // the UUIDv8 of a child encodes its occurrence time
+			t.Errorf("file name %q reveals an occurrence time", entry.Name())
+		}
+	}
+}
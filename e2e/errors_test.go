@@ -0,0 +1,65 @@
+package e2e
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/git-calendar/core/pkg/api"
+	"github.com/google/uuid"
+)
+
+func TestApiErrorCodes(t *testing.T) {
+	a := api.NewApi()
+
+	tests := []struct {
+		name string
+		call func() error
+		want api.ErrorCode
+	}{
+		{
+			name: "event not found",
+			call: func() error { _, err := a.GetEvent(uuid.New().String()); return err },
+			want: api.CodeNotFound,
+		},
+		{
+			name: "invalid id",
+			call: func() error { _, err := a.GetEvent("not-a-uuid"); return err },
+			want: api.CodeInvalidArgument,
+		},
+		{
+			name: "invalid json",
+			call: func() error { _, err := a.CreateEvent(`{"title": `); return err },
+			want: api.CodeInvalidArgument,
+		},
+		{
+			name: "invalid event",
+			call: func() error { _, err := a.CreateEvent(`{"calendar": "test"}`); return err },
+			want: api.CodeInvalidEvent,
+		},
+		{
+			name: "calendar not found",
+			call: func() error { return a.LockCalendar("this-calendar-does-not-exist") },
+			want: api.CodeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+
+			// the message is a JSON object for gomobile clients
+			var got api.Error
+			if jsonErr := json.Unmarshal([]byte(err.Error()), &got); jsonErr != nil {
+				t.Fatalf("error is not a JSON object: %q", err.Error())
+			}
+			if got.Code != tt.want {
+				t.Errorf("code = %s, want %s (message: %s)", got.Code, tt.want, got.Message)
+			}
+			if got.Message == "" {
+				t.Errorf("message is empty")
+			}
+		})
+	}
+}
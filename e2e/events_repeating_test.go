@@ -149,7 +149,7 @@ func TestAddRepeatingEventsAndRemoveRepeatingEvent(t *testing.T) {
 	}
 
 	eventsOut = c.GetEvents(queryFrom, queryTo)
-	if len(eventsOut) != COUNT-1 || slices.Contains(eventsOut, eventToRemove) {
+	if len(eventsOut) != COUNT-1 || slices.ContainsFunc(eventsOut, func(e core.Event) bool { return e.Id == eventToRemove.Id }) {
 		t.Errorf("event wasn't removed correctly; eventsOut: %d: %+v", len(eventsOut), eventsOut)
 	}
 }
@@ -438,3 +438,35 @@ func TestUpdateFollowing_ExceptionCarriedToNewParent(t *testing.T) {
 		}
 	}
 }
+
+func TestAddUntilRepeatingEvent_UntilAtFirstOccurrence(t *testing.T) {
+	const calendarName = "test-until-end"
+	c := newIsolatedCore(t)
+
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	// a series ending right at its first occurrence (what a split of the series at its second occurrence leaves)
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Until Event",
+		From:     date,
+		To:       date.Add(2 * time.Hour),
+		Repeat: &core.Repetition{
+			Frequency: core.Day,
+			Interval:  1,
+			Until:     date,
+		},
+	}
+	if _, err := c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	eventsOut := c.GetEvents(date.AddDate(0, 0, -1), date.AddDate(0, 0, 3))
+	if len(eventsOut) != 1 || !eventsOut[0].From.Equal(date) {
+		t.Errorf("expected only the occurrence at %v, got: %+v", date, eventsOut)
+	}
+}
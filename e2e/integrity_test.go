@@ -0,0 +1,163 @@
+package e2e
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/google/uuid"
+)
+
+func TestCheckIntegrityAndRepair(t *testing.T) {
+	const calendarName = "test-integrity"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	committed, err := c.CreateEvent(core.Event{
+		Calendar: calendarName,
+		Title:    "Committed Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	eventsDir := filepath.Join(repoPathOf(t, calendarName), core.EventsDirName)
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(eventsDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	// break things behind the core's back
+	writeFile(fmt.Sprintf("%s.json", committed.Id), `{"title": "Comm`)
+	strayId := uuid.New()
+	writeFile(fmt.Sprintf("%s.json", strayId), fmt.Sprintf(
+		`{"title": "Stray", "from": "2026-01-02T09:00:00Z", "to": "2026-01-02T10:00:00Z", "calendar": "foo", "parent_id": "%s"}`, uuid.New()),
+	)
+	writeFile(core.TempFilePrefix+"leftover.json", `{`)
+	notesPath := filepath.Join(repoPathOf(t, calendarName), "notes.txt")
+	if err := os.WriteFile(notesPath, []byte("not an event"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	report, err := c.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("failed to check integrity: %v", err)
+	}
+	if raw, _ := json.Marshal(report); !strings.Contains(string(raw), `"event_id"`) {
+		t.Errorf("expected snake_case JSON fields: %s", raw)
+	}
+	kinds := issueKindsOf(report, calendarName)
+	for _, want := range []core.IssueKind{
+		core.IssueCorruptJson, core.IssueWrongCalendar, core.IssueDanglingParent, core.IssueUntracked, core.IssueModified,
+	} {
+		if kinds[want] == 0 {
+			t.Errorf("expected an issue of kind '%s', got: %+v", want, report.Issues)
+		}
+	}
+
+	report, err = c.Repair()
+	if err != nil {
+		t.Fatalf("failed to repair: %v", err)
+	}
+	for _, issue := range report.Issues {
+		// orphans and files outside the events dir are only reported
+		onlyReported := issue.Kind == core.IssueDanglingParent || issue.Path == "notes.txt"
+		if issue.Calendar == calendarName && issue.Repaired == onlyReported {
+			t.Errorf("unexpected repair state of issue: %+v", issue)
+		}
+	}
+
+	report, err = c.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("failed to check integrity: %v", err)
+	}
+	kinds = issueKindsOf(report, calendarName)
+	if len(kinds) != 2 || kinds[core.IssueDanglingParent] != 1 || kinds[core.IssueUntracked] != 1 {
+		t.Errorf("expected only the orphan and the stray file after repair, got: %+v", report.Issues)
+	}
+	if status := worktreeStatus(t, repoPathOf(t, calendarName)); status.File("notes.txt").Staging != gogit.Untracked {
+		t.Errorf("stray file outside the events dir should stay untracked:\n%s", status)
+	}
+
+	// both events are loaded after the repair
+	eventOut, err := c.GetEvent(committed.Id)
+	if err != nil || eventOut.Title != committed.Title {
+		t.Errorf("corrupt event was not restored: %+v (err: %v)", eventOut, err)
+	}
+	stray, err := c.GetEvent(strayId)
+	if err != nil {
+		t.Fatalf("stray event was not loaded: %v", err)
+	}
+	if stray.Calendar != calendarName || stray.ParentId == uuid.Nil {
+		t.Errorf("stray event should get its calendar fixed and keep its parent: %+v", stray)
+	}
+}
+
+func TestLoadCalendars_QuarantinesWrongCalendar(t *testing.T) {
+	const calendarName = "test-integrity-wrong-calendar"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	eventsDir := filepath.Join(repoPathOf(t, calendarName), core.EventsDirName)
+	if err := os.MkdirAll(eventsDir, 0o755); err != nil {
+		t.Fatalf("failed to create events dir: %v", err)
+	}
+	strayId := uuid.New()
+	fileName := fmt.Sprintf("%s.json", strayId)
+	err := os.WriteFile(filepath.Join(eventsDir, fileName), []byte(
+		`{"title": "Stray", "from": "2026-01-02T09:00:00Z", "to": "2026-01-02T10:00:00Z", "calendar": "foo"}`,
+	), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	want := []core.QuarantinedFile{{Calendar: calendarName, Path: core.EventsDirName + "/" + fileName, Kind: core.IssueWrongCalendar}}
+	if got := quarantineOf(c2.ListQuarantine(), calendarName); len(got) != 1 || got[0].Path != want[0].Path || got[0].Kind != want[0].Kind {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if _, err := c2.GetEvent(strayId); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("event with a wrong calendar should not be loaded: %v", err)
+	}
+
+	// Repair fixes it
+	if _, err := c2.Repair(); err != nil {
+		t.Fatalf("failed to repair: %v", err)
+	}
+	if event, err := c2.GetEvent(strayId); err != nil || event.Calendar != calendarName {
+		t.Errorf("expected the repaired event to be loaded: %+v (err: %v)", event, err)
+	}
+}
+
+// Helper
+func issueKindsOf(report *core.IntegrityReport, calendarName string) map[core.IssueKind]int {
+	kinds := make(map[core.IssueKind]int)
+	for _, issue := range report.Issues {
+		if issue.Calendar == calendarName {
+			kinds[issue.Kind]++
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,170 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestLockAndUnlockCalendar(t *testing.T) {
+	const calendarName = "test-lock"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "somepassword")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	eventIn := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Secret Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+	}
+	if _, err = c.CreateEvent(eventIn); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	if err := c.LockCalendar(calendarName); err != nil {
+		t.Fatalf("failed to lock calendar: %v", err)
+	}
+	if _, err := c.GetEvent(eventIn.Id); err == nil {
+		t.Errorf("event should not be available in a locked calendar")
+	}
+	for _, e := range c.GetEvents(date, date.AddDate(0, 0, 1)) {
+		if e.Calendar == calendarName {
+			t.Errorf("locked calendar event returned by GetEvents: %v", e)
+		}
+	}
+	if _, err := c.CreateEvent(core.Event{Calendar: calendarName, Title: "x", From: date, To: date.Add(time.Hour)}); !errors.Is(err, core.ErrLocked) {
+		t.Errorf("expected ErrLocked when writing into a locked calendar, got: %v", err)
+	}
+
+	// stays locked after reload
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	if locked, err := c2.IsCalendarLocked(calendarName); err != nil || !locked {
+		t.Fatalf("calendar should be locked after reload: locked=%v err=%v", locked, err)
+	}
+
+	if err := c2.UnlockCalendar(calendarName, "wrongpassword"); !errors.Is(err, core.ErrWrongPassword) {
+		t.Errorf("expected ErrWrongPassword, got: %v", err)
+	}
+	if err := c2.UnlockCalendar(calendarName, "somepassword"); err != nil {
+		t.Fatalf("failed to unlock calendar: %v", err)
+	}
+
+	eventOut, err := c2.GetEvent(eventIn.Id)
+	if err != nil {
+		t.Fatalf("failed to get an event by id after unlock: %v", err)
+	}
+	if eventOut.Title != eventIn.Title {
+		t.Errorf("title mismatch after unlock: got %q, want %q", eventOut.Title, eventIn.Title)
+	}
+}
+
+func TestAutoLock(t *testing.T) {
+	const calendarName = "test-autolock"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "somepassword")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	c.SetAutoLock(200 * time.Millisecond)
+	for range 3 { // activity keeps it unlocked
+		time.Sleep(50 * time.Millisecond)
+		_ = c.GetEvents(time.Now(), time.Now().Add(time.Hour))
+	}
+	if locked, err := c.IsCalendarLocked(calendarName); err != nil || locked {
+		t.Errorf("calendar should stay unlocked while used: locked=%v err=%v", locked, err)
+	}
+
+	time.Sleep(400 * time.Millisecond) // idle, no call needed
+
+	if locked, err := c.IsCalendarLocked(calendarName); err != nil || !locked {
+		t.Errorf("calendar should be auto-locked: locked=%v err=%v", locked, err)
+	}
+	c.SetAutoLock(0)
+}
+
+func TestUnlockCalendar_EmptyCalendarChecksPassword(t *testing.T) {
+	const calendarName = "test-lock-empty"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, "somepassword"); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := c.LockCalendar(calendarName); err != nil {
+		t.Fatalf("failed to lock calendar: %v", err)
+	}
+
+	if err := c.UnlockCalendar(calendarName, "wrongpassword"); !errors.Is(err, core.ErrWrongPassword) {
+		t.Errorf("expected ErrWrongPassword for an empty calendar, got: %v", err)
+	}
+	if locked, _ := c.IsCalendarLocked(calendarName); !locked {
+		t.Errorf("calendar should stay locked after a wrong password")
+	}
+	if err := c.CreateCalendar(calendarName, "wrongpassword"); !errors.Is(err, core.ErrWrongPassword) {
+		t.Errorf("expected ErrWrongPassword opening the calendar with another password, got: %v", err)
+	}
+	if err := c.UnlockCalendar(calendarName, "somepassword"); err != nil {
+		t.Errorf("failed to unlock with the right password: %v", err)
+	}
+}
+
+func TestAutoLock_UnlockAfterIdle(t *testing.T) {
+	const calendarName = "autolock-unlock"
+	c := newIsolatedCore(t)
+	if err := c.CreateCalendar(calendarName, "somepassword"); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	c.SetAutoLock(200 * time.Millisecond)
+	defer c.SetAutoLock(0)
+	time.Sleep(400 * time.Millisecond) // idle, the timer locks it
+	if locked, _ := c.IsCalendarLocked(calendarName); !locked {
+		t.Fatalf("calendar should be auto-locked")
+	}
+
+	if err := c.UnlockCalendar(calendarName, "somepassword"); err != nil {
+		t.Fatalf("failed to unlock calendar: %v", err)
+	}
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := c.CreateEvent(core.Event{Calendar: calendarName, Title: "After unlock", From: date, To: date.Add(time.Hour)}); err != nil {
+		t.Errorf("the first operation after an unlock shouldn't lock the calendar again: %v", err)
+	}
+}
+
+func TestAutoLock_SettingsChangesAreActivity(t *testing.T) {
+	const calendarName = "autolock-settings"
+	c := newIsolatedCore(t)
+	if err := c.CreateCalendar(calendarName, "somepassword"); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	c.SetAutoLock(300 * time.Millisecond)
+	defer c.SetAutoLock(0)
+	for i := range 4 {
+		time.Sleep(150 * time.Millisecond)
+		if _, err := c.ListTags(calendarName); err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if err := c.SetPrivateMode(calendarName, i%2 == 0); err != nil {
+			t.Fatalf("failed to set private mode: %v", err)
+		}
+	}
+	if locked, _ := c.IsCalendarLocked(calendarName); locked {
+		t.Errorf("calendar should stay unlocked while its settings are changed")
+	}
+}
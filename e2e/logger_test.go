@@ -0,0 +1,55 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestSetLogger_StructuredFields(t *testing.T) {
+	const calendarName = "test-logger"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	eventsDir := filepath.Join(repoPathOf(t, calendarName), core.EventsDirName)
+	if err := os.MkdirAll(eventsDir, 0o755); err != nil {
+		t.Fatalf("failed to create events dir: %v", err)
+	}
+	filename := fmt.Sprintf("%s.json", uuid.New())
+	if err := os.WriteFile(filepath.Join(eventsDir, filename), []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	c.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	if err := c.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+
+	found := false
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log record is not JSON: %q", line)
+		}
+		if record["calendar"] == calendarName && record["file"] == filename && record["op"] == "load" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no log record about the invalid file, got:\n%s", buf.String())
+	}
+}
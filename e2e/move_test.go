@@ -0,0 +1,279 @@
+package e2e
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestUpdateEvent_ChangedCalendarMovesFile(t *testing.T) {
+	const source, target = "test-move-update-src", "test-move-update-dst"
+	c := core.NewCore()
+	for _, name := range []string{source, target} {
+		_ = c.RemoveCalendar(name)
+		if err := c.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	event := core.Event{Id: uuid.New(), Calendar: source, Title: "Wandering", From: date, To: date.Add(time.Hour)}
+	if _, err := c.CreateEvent(event); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	event.Calendar = target
+	if _, err := c.UpdateEvent(event); err != nil {
+		t.Fatalf("failed to update an event: %v", err)
+	}
+
+	fileName := event.Id.String() + ".json"
+	if _, err := os.Stat(filepath.Join(repoPathOf(t, source), core.EventsDirName, fileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("event file left in the original calendar: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoPathOf(t, target), core.EventsDirName, fileName)); err != nil {
+		t.Errorf("event file missing in the target calendar: %v", err)
+	}
+	if n := countCommits(t, repoPathOf(t, source)); n != 2 {
+		t.Errorf("expected 2 commits in the original calendar (create, move out), got %d", n)
+	}
+}
+
+func TestMoveEvent_SeriesWithDetachedIntoEncryptedCalendar(t *testing.T) {
+	const source, target = "test-move-src", "test-move-dst"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(source)
+	_ = c.RemoveCalendar(target)
+	if err := c.CreateCalendar(source, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := c.CreateCalendar(target, "somepassword"); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	const count = 5
+	startTime := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:       uuid.New(),
+		Calendar: source,
+		Title:    "Daily Standup",
+		From:     startTime,
+		To:       startTime.Add(time.Hour),
+		Repeat:   &core.Repetition{Frequency: core.Day, Interval: 1, Count: count},
+	}
+	if _, err := c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+
+	children := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), source)
+	if len(children) != count {
+		t.Fatalf("setup: expected %d events, got %d", count, len(children))
+	}
+	changed := children[1]
+	changed.Title = "Standup (moved to the afternoon)"
+	detached, err := c.UpdateRepeatingEvent(children[1], changed, core.Current)
+	if err != nil {
+		t.Fatalf("failed to detach a child: %v", err)
+	}
+	if detached.DetachedFrom != parent.Id {
+		t.Fatalf("detached event doesn't point to its series: %+v", detached)
+	}
+
+	commitsBefore := countCommits(t, repoPathOf(t, source))
+	targetCommitsBefore := countCommits(t, repoPathOf(t, target)) // the password check
+
+	moved, err := c.MoveEvent(parent.Id, target)
+	if err != nil {
+		t.Fatalf("failed to move the series: %v", err)
+	}
+	if moved.Calendar != target {
+		t.Errorf("moved event has calendar %q, want %q", moved.Calendar, target)
+	}
+
+	if got := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), source); len(got) != 0 {
+		t.Errorf("events left in the original calendar: %+v", got)
+	}
+	if got := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), target); len(got) != count {
+		t.Errorf("expected %d events in the target calendar, got %d", count, len(got))
+	}
+
+	// one commit in each repo
+	if n := countCommits(t, repoPathOf(t, source)); n != commitsBefore+1 {
+		t.Errorf("expected %d commits in the original calendar, got %d", commitsBefore+1, n)
+	}
+	if n := countCommits(t, repoPathOf(t, target)); n != targetCommitsBefore+1 {
+		t.Errorf("expected 1 commit in the target calendar, got %d", n-targetCommitsBefore)
+	}
+
+	// the files are gone from the source and encrypted in the target
+	if entries, _ := os.ReadDir(filepath.Join(repoPathOf(t, source), core.EventsDirName)); len(entries) != 0 {
+		t.Errorf("files left in the original calendar: %d", len(entries))
+	}
+	for _, id := range []uuid.UUID{parent.Id, detached.Id} {
+		raw, err := os.ReadFile(filepath.Join(repoPathOf(t, target), core.EventsDirName, id.String()+".json"))
+		if err != nil {
+			t.Fatalf("failed to read moved file: %v", err)
+		}
+		if strings.Contains(string(raw), "Standup") {
+			t.Errorf("moved file is not encrypted: %s", raw)
+		}
+	}
+
+	// and it survives a reload
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	reloaded, err := c2.GetEvent(detached.Id)
+	if err != nil {
+		t.Fatalf("failed to get the detached event after reload: %v", err)
+	}
+	if reloaded.Calendar != target || reloaded.Title != changed.Title {
+		t.Errorf("detached event not moved correctly: %+v", reloaded)
+	}
+}
+
+func TestMoveEvent_UnknownEventOrCalendar(t *testing.T) {
+	const source, target = "test-move-unknown-src", "test-move-unknown-dst"
+	c := core.NewCore()
+	for _, name := range []string{source, target} {
+		_ = c.RemoveCalendar(name)
+		if err := c.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+
+	if _, err := c.MoveEvent(uuid.New(), target); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown event, got: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	event := core.Event{Id: uuid.New(), Calendar: source, Title: "Stays", From: date, To: date.Add(time.Hour)}
+	if _, err := c.CreateEvent(event); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if _, err := c.MoveEvent(event.Id, "this-calendar-does-not-exist"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown calendar, got: %v", err)
+	}
+	if stored, err := c.GetEvent(event.Id); err != nil || stored.Calendar != source {
+		t.Errorf("event changed after a failed move: %+v (err: %v)", stored, err)
+	}
+}
+
+func TestMoveEvent_LegacyDetachedEventStays(t *testing.T) {
+	const source, target = "legacy-src", "legacy-dst"
+	c := newIsolatedCore(t)
+	for _, name := range []string{source, target} {
+		if err := c.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+
+	const count = 3
+	startTime := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:       uuid.New(),
+		Calendar: source,
+		Title:    "Daily Standup",
+		From:     startTime,
+		To:       startTime.Add(time.Hour),
+		Repeat:   &core.Repetition{Frequency: core.Day, Interval: 1, Count: count},
+	}
+	if _, err := c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+	children := c.GetEvents(startTime, startTime.AddDate(0, 0, count+1))
+	changed := children[1]
+	changed.Title = "Detached Standup"
+	detached, err := c.UpdateRepeatingEvent(children[1], changed, core.Current)
+	if err != nil {
+		t.Fatalf("failed to detach a child: %v", err)
+	}
+
+	// detached by an older version, which didn't record the series
+	legacy := *detached
+	legacy.DetachedFrom = uuid.Nil
+	if _, err := c.UpdateEvent(legacy); err != nil {
+		t.Fatalf("failed to update the detached event: %v", err)
+	}
+
+	if _, err := c.MoveEvent(parent.Id, target); err != nil {
+		t.Fatalf("failed to move the series: %v", err)
+	}
+	got, err := c.GetEvent(detached.Id)
+	if err != nil {
+		t.Fatalf("failed to get the detached event: %v", err)
+	}
+	if got.Calendar != source {
+		t.Errorf("a detached event without DetachedFrom can't be matched to the series, it should stay in %q: %+v", source, got)
+	}
+}
+
+func TestMoveEvent_CategoriesCheckedAgainstTargetTags(t *testing.T) {
+	const source, target = "test-move-tags-src", "test-move-tags-dst"
+	c := core.NewCore()
+	for _, name := range []string{source, target} {
+		_ = c.RemoveCalendar(name)
+		if err := c.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+	if err := c.CreateTag(target, core.Tag{Name: "work"}); err != nil {
+		t.Fatalf("failed to create a tag: %v", err)
+	}
+
+	const count = 3
+	startTime := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:         uuid.New(),
+		Calendar:   source,
+		Title:      "Daily Standup",
+		Categories: []string{"work"},
+		From:       startTime,
+		To:         startTime.Add(time.Hour),
+		Repeat:     &core.Repetition{Frequency: core.Day, Interval: 1, Count: count},
+	}
+	if _, err := c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+	children := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), source)
+	changed := children[1]
+	changed.Categories = []string{"private"} // unknown in the target
+	detached, err := c.UpdateRepeatingEvent(children[1], changed, core.Current)
+	if err != nil {
+		t.Fatalf("failed to detach a child: %v", err)
+	}
+	targetCommitsBefore := countCommits(t, repoPathOf(t, target))
+
+	if _, err := c.MoveEvent(parent.Id, target); !errors.Is(err, core.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent for a detached event with an unknown tag, got: %v", err)
+	}
+	moved := parent
+	moved.Calendar = target
+	if _, err := c.UpdateEvent(moved); !errors.Is(err, core.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent moving the series by an update, got: %v", err)
+	}
+	basic := core.Event{Calendar: source, Title: "Private", Categories: []string{"private"}, From: startTime, To: startTime.Add(time.Hour)}
+	created, err := c.CreateEvent(basic)
+	if err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+	if _, err := c.MoveEvent(created.Id, target); !errors.Is(err, core.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent for an event with an unknown tag, got: %v", err)
+	}
+
+	for _, id := range []uuid.UUID{parent.Id, detached.Id, created.Id} {
+		if got, err := c.GetEvent(id); err != nil || got.Calendar != source {
+			t.Errorf("event moved despite the failure: %+v (err: %v)", got, err)
+		}
+	}
+	if n := countCommits(t, repoPathOf(t, target)); n != targetCommitsBefore {
+		t.Errorf("failed moves created %d commits in the target calendar", n-targetCommitsBefore)
+	}
+}
@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/google/uuid"
+)
+
+func TestNewCoreWithOptions_MemFS(t *testing.T) {
+	fs := memfs.New()
+	c, err := core.NewCoreWithOptions(core.Options{
+		Filesystem:  fs,
+		AuthorName:  "Joe",
+		AuthorEmail: "joe@example.com",
+	})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+
+	if err := c.CreateCalendar("work", ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	eventIn := core.Event{
+		Id:       uuid.New(),
+		Calendar: "work",
+		Title:    "In Memory",
+		From:     date,
+		To:       date.Add(time.Hour),
+	}
+	if _, err := c.CreateEvent(eventIn); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	eventIn.Title = "Updated In Memory"
+	if _, err := c.UpdateEvent(eventIn); err != nil {
+		t.Fatalf("failed to update an event: %v", err)
+	}
+
+	// a second core on the same fs sees the same data
+	c2, err := core.NewCoreWithOptions(core.Options{Filesystem: fs})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	if calendars := c2.ListCalendars(); len(calendars) != 1 || calendars[0].Name != "work" {
+		t.Errorf("expected only the 'work' calendar, got %v", calendars)
+	}
+	eventOut, err := c2.GetEvent(eventIn.Id)
+	if err != nil {
+		t.Fatalf("failed to get an event: %v", err)
+	}
+	if eventOut.Title != eventIn.Title {
+		t.Errorf("title mismatch: got %q, want %q", eventOut.Title, eventIn.Title)
+	}
+
+	// everything is committed
+	report, err := c2.CheckIntegrity()
+	if err != nil || !report.IsClean() {
+		t.Errorf("expected a clean repo, got %+v (err: %v)", report, err)
+	}
+}
+
+func TestNewCoreWithOptions_InvalidProxy(t *testing.T) {
+	if _, err := core.NewCoreWithOptions(core.Options{Filesystem: memfs.New(), ProxyUrl: "not a url"}); err == nil {
+		t.Errorf("expected an error for an invalid proxy url")
+	}
+}
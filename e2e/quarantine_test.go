@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestQuarantine_ResolveAndRemove(t *testing.T) {
+	const calendarName = "test-quarantine"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	eventsDir := filepath.Join(repoPathOf(t, calendarName), core.EventsDirName)
+	if err := os.MkdirAll(eventsDir, 0o755); err != nil {
+		t.Fatalf("failed to create events dir: %v", err)
+	}
+	invalidId := uuid.New()
+	invalidPath := fmt.Sprintf("%s/%s.json", core.EventsDirName, invalidId)
+	garbagePath := fmt.Sprintf("%s/%s.json", core.EventsDirName, uuid.New())
+	files := map[string]string{
+		invalidPath: `{"from": "2026-01-02T09:00:00Z", "to": "2026-01-02T10:00:00Z"}`, // missing title
+		garbagePath: `{"title": "Gar`,
+	}
+	for p, content := range files {
+		if err := os.WriteFile(filepath.Join(repoPathOf(t, calendarName), p), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	if err := c.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+
+	quarantine := quarantineOf(c.ListQuarantine(), calendarName)
+	if len(quarantine) != 2 {
+		t.Fatalf("expected 2 quarantined files, got: %+v", quarantine)
+	}
+	for _, q := range quarantine {
+		if q.Raw != files[q.Path] {
+			t.Errorf("raw content mismatch for %s: %q", q.Path, q.Raw)
+		}
+		wantKind := core.IssueCorruptJson
+		if q.Path == invalidPath {
+			wantKind = core.IssueInvalidEvent
+		}
+		if q.Kind != wantKind {
+			t.Errorf("kind mismatch for %s: got %s, want %s", q.Path, q.Kind, wantKind)
+		}
+	}
+
+	// fix the invalid one
+	date := time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+	resolved, err := c.ResolveQuarantined(calendarName, invalidPath, core.Event{
+		Title: "Fixed Event",
+		From:  date,
+		To:    date.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to resolve quarantined file: %v", err)
+	}
+	if resolved.Id != invalidId {
+		t.Errorf("resolved event should keep the id from the file name, got %s", resolved.Id)
+	}
+	if _, err := c.GetEvent(invalidId); err != nil {
+		t.Errorf("resolved event is not loaded: %v", err)
+	}
+
+	// delete the garbage one
+	if err := c.RemoveQuarantined(calendarName, garbagePath); err != nil {
+		t.Fatalf("failed to remove quarantined file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoPathOf(t, calendarName), garbagePath)); !os.IsNotExist(err) {
+		t.Errorf("quarantined file still exists on disk")
+	}
+	if err := c.RemoveQuarantined(calendarName, garbagePath); err == nil {
+		t.Errorf("expected an error when removing a file which is not quarantined")
+	}
+
+	if quarantine := quarantineOf(c.ListQuarantine(), calendarName); len(quarantine) != 0 {
+		t.Errorf("expected empty quarantine, got: %+v", quarantine)
+	}
+
+	// nothing is quarantined after reload either
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	if quarantine := quarantineOf(c2.ListQuarantine(), calendarName); len(quarantine) != 0 {
+		t.Errorf("expected empty quarantine after reload, got: %+v", quarantine)
+	}
+}
+
+// Helper
+func quarantineOf(files []core.QuarantinedFile, calendarName string) []core.QuarantinedFile {
+	result := []core.QuarantinedFile{}
+	for _, f := range files {
+		if f.Calendar == calendarName {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/git-calendar/core/pkg/filesystem"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/google/uuid"
+)
+
+func TestLoadCalendars_RecoversTruncatedEventFile(t *testing.T) {
+	const calendarName = "test-recovery"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	eventIn := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Foo Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+	}
+	if _, err = c.CreateEvent(eventIn); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("failed to get home dir: %v", err)
+	}
+	repoPath := filepath.Join(home, filesystem.DirName, calendarName)
+	eventsDir := filepath.Join(repoPath, core.EventsDirName)
+	filePath := filepath.Join(eventsDir, fmt.Sprintf("%s.json", eventIn.Id))
+	commitsBefore := countCommits(t, repoPath)
+	tmpPath := filepath.Join(eventsDir, core.TempFilePrefix+"leftover.json")
+
+	// simulate a crash in the middle of a write
+	if err := os.WriteFile(filePath, []byte(`{"title": "Fo`), 0o644); err != nil {
+		t.Fatalf("failed to truncate event file: %v", err)
+	}
+	if err := os.WriteFile(tmpPath, []byte(`{`), 0o644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+
+	eventOut, err := c2.GetEvent(eventIn.Id)
+	if err != nil {
+		t.Fatalf("event was not recovered: %v", err)
+	}
+	if eventOut.Title != eventIn.Title {
+		t.Errorf("title mismatch after recovery: got %q, want %q", eventOut.Title, eventIn.Title)
+	}
+
+	if b, err := os.ReadFile(filePath); err != nil || len(b) < len(`{"title": "Fo`)+10 {
+		t.Errorf("event file was not restored on disk: %s (err: %v)", b, err)
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Errorf("leftover temp file was not removed")
+	}
+
+	// the restore leaves nothing to commit
+	if n := countCommits(t, repoPath); n != commitsBefore {
+		t.Errorf("restore created commits: got %d, want %d", n, commitsBefore)
+	}
+	if status := worktreeStatus(t, repoPath); !status.IsClean() {
+		t.Errorf("restore left changes in the worktree or index:\n%s", status)
+	}
+}
+
+func TestLoadCalendars_QuarantinesTruncatedUncommittedFile(t *testing.T) {
+	const calendarName = "test-recovery-uncommitted"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := c.CreateEvent(core.Event{Calendar: calendarName, Title: "Committed", From: date, To: date.Add(time.Hour)}); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	repoPath := repoPathOf(t, calendarName)
+	commitsBefore := countCommits(t, repoPath)
+
+	// a crash while writing a new event, before it was ever committed
+	fileName := fmt.Sprintf("%s.json", uuid.New())
+	if err := os.WriteFile(filepath.Join(repoPath, core.EventsDirName, fileName), []byte(`{"title": "Fo`), 0o644); err != nil {
+		t.Fatalf("failed to write event file: %v", err)
+	}
+
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+
+	var quarantined bool
+	for _, q := range c2.ListQuarantine() {
+		if q.Calendar == calendarName && q.Path == core.EventsDirName+"/"+fileName {
+			quarantined = true
+		}
+	}
+	if !quarantined {
+		t.Errorf("uncommitted truncated file should be quarantined: %+v", c2.ListQuarantine())
+	}
+	if b, err := os.ReadFile(filepath.Join(repoPath, core.EventsDirName, fileName)); err != nil || string(b) != `{"title": "Fo` {
+		t.Errorf("quarantined file should be kept as it is: %s (err: %v)", b, err)
+	}
+	if n := countCommits(t, repoPath); n != commitsBefore {
+		t.Errorf("load created commits: got %d, want %d", n, commitsBefore)
+	}
+	if status := worktreeStatus(t, repoPath); status.File(core.EventsDirName+"/"+fileName).Staging != gogit.Untracked {
+		t.Errorf("quarantined file should stay untracked:\n%s", status)
+	}
+}
+
+func worktreeStatus(t *testing.T, repoPath string) gogit.Status {
+	t.Helper()
+	repo, err := gogit.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatalf("failed to open repo: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	return status
+}
@@ -0,0 +1,150 @@
+package e2e
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestRenameCalendar_EncryptedWithSeries(t *testing.T) {
+	const oldName, newName, password = "test-rename-old", "test-rename-new", "somepassword"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(oldName)
+	_ = c.RemoveCalendar(newName)
+	if err := c.CreateCalendar(oldName, password); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	const count = 4
+	startTime := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:       uuid.New(),
+		Calendar: oldName,
+		Title:    "Daily Standup",
+		From:     startTime,
+		To:       startTime.Add(time.Hour),
+		Repeat:   &core.Repetition{Frequency: core.Day, Interval: 1, Count: count},
+	}
+	if _, err := c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+	children := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), oldName)
+	changed := children[2]
+	changed.Title = "Longer Standup"
+	detached, err := c.UpdateRepeatingEvent(children[2], changed, core.Current)
+	if err != nil {
+		t.Fatalf("failed to detach a child: %v", err)
+	}
+
+	if err := c.RenameCalendar(oldName, newName); err != nil {
+		t.Fatalf("failed to rename calendar: %v", err)
+	}
+
+	if names := calendarNames(c.ListCalendars()); slices.Contains(names, oldName) || !slices.Contains(names, newName) {
+		t.Errorf("calendar not renamed in the list: %v", names)
+	}
+	if _, err := os.Stat(repoPathOf(t, oldName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("old repo directory still exists: %v", err)
+	}
+	if got := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), newName); len(got) != count {
+		t.Errorf("expected %d events in the renamed calendar, got %d", count, len(got))
+	}
+
+	// the same password still unlocks it, also after reload
+	if err := c.LockCalendar(newName); err != nil {
+		t.Fatalf("failed to lock calendar: %v", err)
+	}
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	if err := c2.UnlockCalendar(newName, password); err != nil {
+		t.Fatalf("failed to unlock the renamed calendar: %v", err)
+	}
+	reloaded, err := c2.GetEvent(detached.Id)
+	if err != nil {
+		t.Fatalf("failed to get the detached event after reload: %v", err)
+	}
+	if reloaded.Calendar != newName || reloaded.Title != changed.Title {
+		t.Errorf("event not rewritten correctly: %+v", reloaded)
+	}
+	if got := eventsOfCalendar(c2.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), newName); len(got) != count {
+		t.Errorf("expected %d events after reload, got %d", count, len(got))
+	}
+}
+
+func TestRenameCalendar_TakenOrInvalidName(t *testing.T) {
+	const first, second = "test-rename-first", "test-rename-second"
+	c := core.NewCore()
+	for _, name := range []string{first, second} {
+		_ = c.RemoveCalendar(name)
+		if err := c.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+
+	if err := c.RenameCalendar(first, second); !errors.Is(err, core.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got: %v", err)
+	}
+	if err := c.RenameCalendar(first, "../escape"); err == nil {
+		t.Errorf("expected an error for an invalid name")
+	}
+	if err := c.RenameCalendar("this-calendar-does-not-exist", "whatever"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestDuplicateCalendar_NewIdsAndExceptions(t *testing.T) {
+	const source, fork = "test-duplicate-src", "test-duplicate-fork"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(source)
+	_ = c.RemoveCalendar(fork)
+	if err := c.CreateCalendar(source, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	const count = 5
+	startTime := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	parent := core.Event{
+		Id:       uuid.New(),
+		Calendar: source,
+		Title:    "Daily Standup",
+		From:     startTime,
+		To:       startTime.Add(time.Hour),
+		Repeat:   &core.Repetition{Frequency: core.Day, Interval: 1, Count: count},
+	}
+	if _, err := c.CreateEvent(parent); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+	children := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), source)
+	if err := c.RemoveRepeatingEvent(children[1], core.Current); err != nil {
+		t.Fatalf("failed to remove a child: %v", err)
+	}
+
+	if err := c.DuplicateCalendar(source, fork); err != nil {
+		t.Fatalf("failed to duplicate calendar: %v", err)
+	}
+
+	original := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), source)
+	copied := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+1)), fork)
+	if len(original) != count-1 || len(copied) != count-1 {
+		t.Fatalf("expected %d events in both calendars, got %d and %d", count-1, len(original), len(copied))
+	}
+	for i := range copied {
+		if copied[i].Id == original[i].Id || copied[i].ParentId == parent.Id {
+			t.Errorf("duplicated event shares the id with the original: %+v", copied[i])
+		}
+		if !copied[i].From.Equal(original[i].From) {
+			t.Errorf("duplicated event at %v, original at %v", copied[i].From, original[i].From)
+		}
+	}
+
+	if n := countCommits(t, repoPathOf(t, fork)); n != 1 {
+		t.Errorf("expected the fork to have 1 commit, got %d", n)
+	}
+}
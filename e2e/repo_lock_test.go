@@ -0,0 +1,224 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestRepoLock_TwoInstancesWriteSameCalendar(t *testing.T) {
+	const calendarName = "shared"
+	root := t.TempDir()
+
+	instances := make([]*core.Core, 2)
+	for i := range instances {
+		c, err := core.NewCoreWithOptions(core.Options{RootPath: root})
+		if err != nil {
+			t.Fatalf("failed to create core: %v", err)
+		}
+		instances[i] = c
+	}
+	if err := instances[0].CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := instances[1].LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+
+	const eventsPerInstance = 10
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(instances)*eventsPerInstance)
+	for i, c := range instances {
+		wg.Go(func() {
+			for j := range eventsPerInstance {
+				start := date.AddDate(0, 0, i*eventsPerInstance+j)
+				_, err := c.CreateEvent(core.Event{
+					Calendar: calendarName,
+					Title:    "Shared Event",
+					From:     start,
+					To:       start.Add(time.Hour),
+				})
+				if err != nil {
+					errs <- err
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent write failed: %v", err)
+	}
+
+	// no commit may get lost and the repo must stay consistent
+	if n := countCommits(t, filepath.Join(root, calendarName)); n != len(instances)*eventsPerInstance {
+		t.Errorf("expected %d commits, got %d", len(instances)*eventsPerInstance, n)
+	}
+
+	fresh, err := core.NewCoreWithOptions(core.Options{RootPath: root})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	if err := fresh.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	events := fresh.GetEvents(date, date.AddDate(0, 0, len(instances)*eventsPerInstance+1))
+	if len(events) != len(instances)*eventsPerInstance {
+		t.Errorf("expected %d events, got %d", len(instances)*eventsPerInstance, len(events))
+	}
+	report, err := fresh.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("failed to check integrity: %v", err)
+	}
+	if !report.IsClean() {
+		t.Errorf("expected a clean repo, got issues: %+v", report.Issues)
+	}
+}
+
+func TestReloadChanged_AfterNotification(t *testing.T) {
+	const calendarName = "notified"
+	root := t.TempDir()
+
+	changes := make(chan string, 10)
+	writer, err := core.NewCoreWithOptions(core.Options{
+		RootPath: root,
+		Notifier: notifierFunc(func(calendar string) { changes <- calendar }),
+	})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	reader, err := core.NewCoreWithOptions(core.Options{RootPath: root})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+
+	if err := writer.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if got := <-changes; got != calendarName {
+		t.Errorf("expected a notification about %q, got %q", calendarName, got)
+	}
+	changed, err := reader.ReloadChanged()
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if !slices.Contains(changed, calendarName) {
+		t.Errorf("new calendar not reported as changed: %v", changed)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	eventIn := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Written Elsewhere",
+		From:     date,
+		To:       date.Add(time.Hour),
+	}
+	if _, err := writer.CreateEvent(eventIn); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if got := <-changes; got != calendarName {
+		t.Errorf("expected a notification about %q, got %q", calendarName, got)
+	}
+
+	if _, err := reader.GetEvent(eventIn.Id); err == nil {
+		t.Fatalf("setup: the reader should not see the event before reload")
+	}
+	changed, err = reader.ReloadChanged()
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if !slices.Equal(changed, []string{calendarName}) {
+		t.Errorf("expected only %q to be changed, got %v", calendarName, changed)
+	}
+	if _, err := reader.GetEvent(eventIn.Id); err != nil {
+		t.Errorf("event not visible after reload: %v", err)
+	}
+
+	// nothing changed since
+	if changed, err = reader.ReloadChanged(); err != nil || len(changed) != 0 {
+		t.Errorf("expected no changes, got %v (err: %v)", changed, err)
+	}
+	if changed, err = writer.ReloadChanged(); err != nil || len(changed) != 0 {
+		t.Errorf("own writes reported as changes: %v (err: %v)", changed, err)
+	}
+}
+
+// Helper
+type notifierFunc func(calendar string)
+
+func (f notifierFunc) Notify(calendar string) { f(calendar) }
+
+func TestRepoLock_ExpiredLeaseTakenOver(t *testing.T) {
+	const calendarName = "abandoned"
+	root := t.TempDir()
+
+	instances := make([]*core.Core, 4)
+	for i := range instances {
+		c, err := core.NewCoreWithOptions(core.Options{RootPath: root})
+		if err != nil {
+			t.Fatalf("failed to create core: %v", err)
+		}
+		instances[i] = c
+	}
+	if err := instances[0].CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	for _, c := range instances[1:] {
+		if err := c.LoadCalendars(); err != nil {
+			t.Fatalf("failed to load calendars: %v", err)
+		}
+	}
+
+	// an instance crashed while holding the lease
+	lockPath := filepath.Join(root, calendarName+".repo-lock")
+	if err := os.WriteFile(lockPath, []byte("crashed-instance"), 0o644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+	expired := time.Now().Add(-2 * core.RepoLeaseTTL)
+	if err := os.Chtimes(lockPath, expired, expired); err != nil {
+		t.Fatalf("failed to age lock file: %v", err)
+	}
+
+	// all of them find the lease expired at once, but only one may take it over at a time
+	const eventsPerInstance = 5
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	errs := make(chan error, len(instances)*eventsPerInstance)
+	for i, c := range instances {
+		wg.Go(func() {
+			for j := range eventsPerInstance {
+				start := date.AddDate(0, 0, i*eventsPerInstance+j)
+				if _, err := c.CreateEvent(core.Event{Calendar: calendarName, Title: "Event", From: start, To: start.Add(time.Hour)}); err != nil {
+					errs <- err
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent write failed: %v", err)
+	}
+
+	if n := countCommits(t, filepath.Join(root, calendarName)); n != len(instances)*eventsPerInstance {
+		t.Errorf("expected %d commits, got %d", len(instances)*eventsPerInstance, n)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("failed to list root: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			t.Errorf("lock file left behind: %s", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,688 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/api"
+	"github.com/git-calendar/core/pkg/core"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/uuid"
+)
+
+// Creates a bare repository to be used as a remote, returns its file url.
+func bareRemote(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".git")
+	if _, err := gogit.PlainInit(path, true); err != nil {
+		t.Fatalf("failed to init bare repo: %v", err)
+	}
+	return "file://" + path
+}
+
+func newIsolatedCore(t *testing.T) *core.Core {
+	t.Helper()
+	c, err := core.NewCoreWithOptions(core.Options{RootPath: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	return c
+}
+
+func TestPushCloneFetchPull(t *testing.T) {
+	const calendarName = "shared"
+	remoteUrl := bareRemote(t, calendarName)
+	ctx := context.Background()
+
+	alice := newIsolatedCore(t)
+	if err := alice.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := alice.AddRemote(calendarName, "origin", remoteUrl); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+	date := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	first := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "First", From: date, To: date.Add(time.Hour)}
+	if _, err := alice.CreateEvent(first); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if err := alice.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	bob := newIsolatedCore(t)
+	parsedUrl, _ := url.Parse(remoteUrl)
+	if err := bob.CloneCalendarContext(ctx, parsedUrl, ""); err != nil {
+		t.Fatalf("failed to clone: %v", err)
+	}
+	if err := bob.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	if _, err := bob.GetEvent(first.Id); err != nil {
+		t.Errorf("cloned calendar is missing the event: %v", err)
+	}
+
+	second := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "Second", From: date.Add(2 * time.Hour), To: date.Add(3 * time.Hour)}
+	if _, err := alice.CreateEvent(second); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if err := alice.Push(ctx, calendarName, "origin"); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	if err := bob.Fetch(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to fetch: %v", err)
+	}
+	if _, err := bob.GetEvent(second.Id); err == nil {
+		t.Errorf("fetch should not change the events")
+	}
+	if err := bob.Pull(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to pull: %v", err)
+	}
+	if _, err := bob.GetEvent(second.Id); err != nil {
+		t.Errorf("pulled event is missing: %v", err)
+	}
+	if err := bob.Pull(ctx, calendarName, ""); err != nil {
+		t.Errorf("pull without changes should succeed: %v", err)
+	}
+}
+
+func TestPull_KeepsUncommittedChanges(t *testing.T) {
+	const calendarName = "shared"
+	remoteUrl := bareRemote(t, calendarName)
+	ctx := context.Background()
+
+	alice := newIsolatedCore(t)
+	if err := alice.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := alice.AddRemote(calendarName, "origin", remoteUrl); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+	date := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	first := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "First", From: date, To: date.Add(time.Hour)}
+	if _, err := alice.CreateEvent(first); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if err := alice.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	bobRoot := t.TempDir()
+	bob, err := core.NewCoreWithOptions(core.Options{RootPath: bobRoot})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	parsedUrl, _ := url.Parse(remoteUrl)
+	if err := bob.CloneCalendarContext(ctx, parsedUrl, ""); err != nil {
+		t.Fatalf("failed to clone: %v", err)
+	}
+
+	second := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "Second", From: date.Add(2 * time.Hour), To: date.Add(3 * time.Hour)}
+	if _, err := alice.CreateEvent(second); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if err := alice.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	// bob's worktree has a change git doesn't know about, and an untracked file
+	eventsDir := filepath.Join(bobRoot, calendarName, core.EventsDirName)
+	modifiedPath := filepath.Join(eventsDir, first.Id.String()+".json")
+	committed, err := os.ReadFile(modifiedPath)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	modified := []byte(`{"title": "Edited outside"}`)
+	untrackedPath := filepath.Join(eventsDir, "notes.txt")
+	for path, content := range map[string][]byte{modifiedPath: modified, untrackedPath: []byte("notes")} {
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	if err := bob.Pull(ctx, calendarName, ""); !errors.Is(err, core.ErrUncommitted) {
+		t.Fatalf("expected ErrUncommitted when pulling over a modified file, got: %v", err)
+	}
+	if raw, err := os.ReadFile(modifiedPath); err != nil || string(raw) != string(modified) {
+		t.Errorf("the pull discarded the uncommitted change: %s (err: %v)", raw, err)
+	}
+
+	// the untracked file alone stops the pull as well, the reset would remove it
+	if err := os.WriteFile(modifiedPath, committed, 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := bob.Pull(ctx, calendarName, ""); !errors.Is(err, core.ErrUncommitted) {
+		t.Fatalf("expected ErrUncommitted when pulling over an untracked file, got: %v", err)
+	}
+	if _, err := os.Stat(untrackedPath); err != nil {
+		t.Errorf("the pull removed the untracked file: %v", err)
+	}
+
+	if err := os.Remove(untrackedPath); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	if err := bob.Pull(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to pull: %v", err)
+	}
+	if _, err := bob.GetEvent(second.Id); err != nil {
+		t.Errorf("pulled event is missing: %v", err)
+	}
+}
+
+func TestSync_CancelledAndMissingRemote(t *testing.T) {
+	const calendarName = "cancelled"
+	c := newIsolatedCore(t)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	if err := c.Fetch(context.Background(), calendarName, "origin"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing remote, got %v", err)
+	}
+
+	if err := c.AddRemote(calendarName, "origin", bareRemote(t, calendarName)); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Push(ctx, calendarName, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	parsedUrl, _ := url.Parse(bareRemote(t, "other"))
+	if err := c.CloneCalendarContext(ctx, parsedUrl, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled for the clone, got %v", err)
+	}
+	for _, info := range c.ListCalendars() {
+		if info.Name == "other" {
+			t.Errorf("cancelled clone left the calendar behind")
+		}
+	}
+}
+
+func TestSyncAll_ResultPerCalendarAndRemote(t *testing.T) {
+	date := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	newEvent := func(calendar string) core.Event {
+		return core.Event{Id: uuid.New(), Calendar: calendar, Title: "Sync", From: date, To: date.Add(time.Hour)}
+	}
+
+	alice := newIsolatedCore(t)
+	for _, name := range []string{"b-shared", "a-local"} {
+		if err := alice.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+	sharedUrl := bareRemote(t, "b-shared")
+	if err := alice.AddRemote("b-shared", "origin", sharedUrl); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+	if err := alice.AddRemote("b-shared", "backup", "http://127.0.0.1:1/calendar.git"); err != nil { // nothing listens there
+		t.Fatalf("failed to add remote: %v", err)
+	}
+	if _, err := alice.CreateEvent(newEvent("b-shared")); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	statuses := func(results []core.SyncResult, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("failed to reload: %v", err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Calendar+"/"+r.Remote+":"+string(r.Status))
+		}
+		return got
+	}
+
+	if got, want := statuses(alice.PushAll()), []string{"b-shared/backup:network", "b-shared/origin:ok"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := statuses(alice.PullAll()), []string{"b-shared/origin:up_to_date"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// bob pushes first, alice's history diverges
+	bob := newIsolatedCore(t)
+	parsedUrl, _ := url.Parse(sharedUrl)
+	if err := bob.CloneCalendarContext(context.Background(), parsedUrl, ""); err != nil {
+		t.Fatalf("failed to clone: %v", err)
+	}
+	if err := bob.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	bobs := newEvent("b-shared")
+	if _, err := bob.CreateEvent(bobs); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if got, want := statuses(bob.PushAll()), []string{"b-shared/origin:ok"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, err := alice.CreateEvent(newEvent("b-shared")); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if got, want := statuses(alice.PushAll()), []string{"b-shared/backup:network", "b-shared/origin:ok"}; !slices.Equal(got, want) {
+		t.Errorf("expected the diverged push to be merged, got %v", got)
+	}
+	if _, err := alice.GetEvent(bobs.Id); err != nil {
+		t.Errorf("the merged event of bob is missing: %v", err)
+	}
+}
+
+func TestPush_MergesDivergedEvents(t *testing.T) {
+	const calendarName = "merged"
+	remoteUrl := bareRemote(t, calendarName)
+	ctx := context.Background()
+	date := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+
+	alice := newIsolatedCore(t)
+	if err := alice.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := alice.AddRemote(calendarName, "origin", remoteUrl); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+	shared := map[string]core.Event{}
+	for _, title := range []string{"Fields", "Same field", "Invalid", "Removed"} {
+		event := core.Event{Id: uuid.New(), Calendar: calendarName, Title: title, From: date, To: date.Add(time.Hour)}
+		if _, err := alice.CreateEvent(event); err != nil {
+			t.Fatalf("failed to create an event: %v", err)
+		}
+		shared[title] = event
+	}
+	if err := alice.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	bob := newIsolatedCore(t)
+	parsedUrl, _ := url.Parse(remoteUrl)
+	if err := bob.CloneCalendarContext(ctx, parsedUrl, ""); err != nil {
+		t.Fatalf("failed to clone: %v", err)
+	}
+	if err := bob.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+
+	update := func(c *core.Core, title string, change func(e *core.Event)) {
+		t.Helper()
+		event := shared[title]
+		change(&event)
+		if _, err := c.UpdateEvent(event); err != nil {
+			t.Fatalf("failed to update event '%s': %v", title, err)
+		}
+	}
+	update(bob, "Fields", func(e *core.Event) { e.Title = "Fields by bob" })
+	update(bob, "Same field", func(e *core.Event) { e.Location = "Bob's office" })
+	update(bob, "Invalid", func(e *core.Event) { e.From, e.To = date.Add(2*time.Hour), date.Add(3*time.Hour) })
+	if err := bob.RemoveEvent(shared["Removed"]); err != nil {
+		t.Fatalf("failed to remove an event: %v", err)
+	}
+	if err := bob.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	update(alice, "Fields", func(e *core.Event) { e.Location = "Room 1" })
+	update(alice, "Same field", func(e *core.Event) { e.Location = "Alice's office" })
+	update(alice, "Invalid", func(e *core.Event) { e.To = date.Add(30 * time.Minute) }) // with bob's start, it would end before it starts
+	if err := alice.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("expected the push to merge the remote, got %v", err)
+	}
+
+	check := func(c *core.Core, who string) {
+		t.Helper()
+		get := func(title string) *core.Event {
+			event, err := c.GetEvent(shared[title].Id)
+			if err != nil {
+				t.Fatalf("%s: event '%s' is missing: %v", who, title, err)
+			}
+			return event
+		}
+		if e := get("Fields"); e.Title != "Fields by bob" || e.Location != "Room 1" {
+			t.Errorf("%s: expected the changes of both sides, got %+v", who, e)
+		}
+		if e := get("Same field"); e.Location != "Alice's office" {
+			t.Errorf("%s: expected the local change to win, got %+v", who, e)
+		}
+		if e := get("Invalid"); !e.From.Equal(date) || !e.To.Equal(date.Add(30*time.Minute)) {
+			t.Errorf("%s: expected the local version of an invalid merge, got %+v", who, e)
+		}
+		if _, err := c.GetEvent(shared["Removed"].Id); !errors.Is(err, core.ErrNotFound) {
+			t.Errorf("%s: expected the removed event to stay removed, got %v", who, err)
+		}
+	}
+	check(alice, "alice")
+
+	if err := bob.Pull(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to pull: %v", err)
+	}
+	check(bob, "bob")
+}
+
+func TestPush_DoesntBlockOtherCalendars(t *testing.T) {
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		<-release // a hanging server
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := newIsolatedCore(t)
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	for _, name := range []string{"slow", "other"} {
+		if err := c.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+		if _, err := c.CreateEvent(core.Event{Calendar: name, Title: "Event", From: date, To: date.Add(time.Hour)}); err != nil {
+			t.Fatalf("failed to create an event: %v", err)
+		}
+	}
+	if err := c.AddRemote("slow", "origin", server.URL+"/slow.git"); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+
+	pushErr := make(chan error, 1)
+	go func() { pushErr <- c.Push(context.Background(), "slow", "") }()
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatalf("push didn't reach the server")
+	}
+
+	// the push hangs in the network, the Core stays usable meanwhile
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.CreateEvent(core.Event{Calendar: "other", Title: "Meanwhile", From: date, To: date.Add(time.Hour)})
+		_ = c.GetEvents(date, date.AddDate(0, 0, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("failed to create an event during the push: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("the Core was blocked by the push of another calendar")
+		defer func() { <-done }()
+	}
+
+	close(release)
+	if err := <-pushErr; err == nil {
+		t.Errorf("expected the push to the failing server to fail")
+	}
+}
+
+func TestSyncAll_NoRemotes(t *testing.T) {
+	a, err := api.NewApiWithOptions(t.TempDir(), "", "", "")
+	if err != nil {
+		t.Fatalf("failed to create api: %v", err)
+	}
+	if err := a.CreateCalendar("local", ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	for op, sync := range map[string]func() (string, error){"PushAll": a.PushAll, "PullAll": a.PullAll} {
+		got, err := sync()
+		if err != nil {
+			t.Errorf("%s failed: %v", op, err)
+		}
+		if got != "[]" {
+			t.Errorf("%s: expected an empty JSON array, got %s", op, got)
+		}
+	}
+}
+
+func TestPush_MergesChangedEncryptionSettings(t *testing.T) {
+	const calendarName = "encrypted"
+	const password = "somepassword"
+	remoteUrl := bareRemote(t, calendarName)
+	ctx := context.Background()
+	date := time.Date(2026, 8, 1, 9, 0, 0, 0, time.UTC)
+
+	aliceRoot := t.TempDir()
+	alice, err := core.NewCoreWithOptions(core.Options{RootPath: aliceRoot})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	if err := alice.CreateCalendar(calendarName, password); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := alice.AddRemote(calendarName, "origin", remoteUrl); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+	shared := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "Shared", From: date, To: date.Add(time.Hour)}
+	if _, err := alice.CreateEvent(shared); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if err := alice.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	// bob only changes how the events are encrypted
+	bob := newIsolatedCore(t)
+	parsedUrl, _ := url.Parse(remoteUrl)
+	if err := bob.CloneCalendarContext(ctx, parsedUrl, password); err != nil {
+		t.Fatalf("failed to clone: %v", err)
+	}
+	if err := bob.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	if err := bob.SetPlaintextFields(calendarName, []string{"from", "to"}); err != nil {
+		t.Fatalf("failed to set plaintext fields: %v", err)
+	}
+	if err := bob.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to push: %v", err)
+	}
+
+	// alice changes the shared event and adds one, both with the old settings
+	shared.Location = "Room 1"
+	if _, err := alice.UpdateEvent(shared); err != nil {
+		t.Fatalf("failed to update an event: %v", err)
+	}
+	added := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "Added", From: date.Add(2 * time.Hour), To: date.Add(3 * time.Hour)}
+	if _, err := alice.CreateEvent(added); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+	if err := alice.Push(ctx, calendarName, ""); err != nil {
+		t.Fatalf("expected the push to merge the remote, got %v", err)
+	}
+
+	// all files are encoded with bob's settings
+	for _, event := range []core.Event{shared, added} {
+		raw, err := os.ReadFile(filepath.Join(aliceRoot, calendarName, core.EventsDirName, event.Id.String()+".json"))
+		if err != nil {
+			t.Fatalf("failed to read event file: %v", err)
+		}
+		var parsed struct {
+			Title string    `json:"title"`
+			From  time.Time `json:"from"`
+		}
+		if err := json.Unmarshal(raw, &parsed); err != nil || !parsed.From.Equal(event.From) || parsed.Title == event.Title {
+			t.Errorf("event '%s' is not encoded with the merged settings: %s (err: %v)", event.Title, raw, err)
+		}
+	}
+
+	check := func(c *core.Core, who string) {
+		t.Helper()
+		if quarantined := quarantineOf(c.ListQuarantine(), calendarName); len(quarantined) != 0 {
+			t.Errorf("%s: expected no quarantined files, got %+v", who, quarantined)
+		}
+		if e, err := c.GetEvent(shared.Id); err != nil || e.Location != shared.Location {
+			t.Errorf("%s: expected the merged shared event, got %+v (err: %v)", who, e, err)
+		}
+		if e, err := c.GetEvent(added.Id); err != nil || e.Title != added.Title {
+			t.Errorf("%s: expected the added event, got %+v (err: %v)", who, e, err)
+		}
+	}
+	reloaded, err := core.NewCoreWithOptions(core.Options{RootPath: aliceRoot})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	if err := reloaded.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	check(reloaded, "alice")
+
+	if err := bob.Pull(ctx, calendarName, ""); err != nil {
+		t.Fatalf("failed to pull: %v", err)
+	}
+	check(bob, "bob")
+}
+
+func TestClone_DoesntBlockCore(t *testing.T) {
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		<-release // a hanging server
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := newIsolatedCore(t)
+	if err := c.CreateCalendar("other", ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	cloneErr := make(chan error, 1)
+	go func() {
+		repoUrl, _ := url.Parse(server.URL + "/slow.git")
+		cloneErr <- c.CloneCalendar(repoUrl, "")
+	}()
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatalf("clone didn't reach the server")
+	}
+
+	// the clone hangs in the network, the Core stays usable meanwhile and doesn't load the half-cloned calendar
+	done := make(chan error, 1)
+	go func() {
+		date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+		_, err := c.CreateEvent(core.Event{Calendar: "other", Title: "Meanwhile", From: date, To: date.Add(time.Hour)})
+		done <- errors.Join(err, c.LoadCalendars())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("failed to use the Core during the clone: %v", err)
+		}
+		if names := calendarNames(c.ListCalendars()); !slices.Equal(names, []string{"other"}) {
+			t.Errorf("expected only the loaded calendar during the clone, got %v", names)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("the Core was blocked by the clone")
+		defer func() { <-done }()
+	}
+
+	close(release)
+	if err := <-cloneErr; err == nil {
+		t.Errorf("expected the clone from the failing server to fail")
+	}
+}
+
+func TestClone_RemovesDirectoryOnFailure(t *testing.T) {
+	// a repository with a corrupt metadata file, cloning succeeds but loading it doesn't
+	source := filepath.Join(t.TempDir(), "broken")
+	repo, err := gogit.PlainInit(source, false)
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, core.MetadataFileName), []byte(`{"version": `), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, err := wt.Add(core.MetadataFileName); err != nil {
+		t.Fatalf("failed to stage: %v", err)
+	}
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("corrupt metadata", &gogit.CommitOptions{Author: signature}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	root := t.TempDir()
+	c, err := core.NewCoreWithOptions(core.Options{RootPath: root})
+	if err != nil {
+		t.Fatalf("failed to create core: %v", err)
+	}
+	repoUrl, _ := url.Parse("file://" + source)
+	if err := c.CloneCalendar(repoUrl, ""); err == nil {
+		t.Fatalf("expected the clone of a repository with corrupt metadata to fail")
+	}
+	if _, err := os.Stat(filepath.Join(root, "broken")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the cloned directory was left on disk: %v", err)
+	}
+}
+
+func TestApi_RejectsRunningOperationId(t *testing.T) {
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		<-release // a hanging server
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	a, err := api.NewApiWithOptions(t.TempDir(), "", "", "")
+	if err != nil {
+		t.Fatalf("failed to create api: %v", err)
+	}
+	for _, name := range []string{"slow", "other"} {
+		if err := a.CreateCalendar(name, ""); err != nil {
+			t.Fatalf("failed to init repo: %v", err)
+		}
+	}
+	if err := a.AddRemote("slow", "origin", server.URL+"/slow.git"); err != nil {
+		t.Fatalf("failed to add remote: %v", err)
+	}
+
+	fetchErr := make(chan error, 1)
+	go func() { fetchErr <- a.Fetch("op", "slow", "", nil) }()
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatalf("fetch didn't reach the server")
+	}
+
+	// the id is taken while the first fetch runs, Cancel must still reach the first one
+	err = a.Fetch("op", "other", "", nil)
+	var got api.Error
+	if err == nil || json.Unmarshal([]byte(err.Error()), &got) != nil || got.Code != api.CodeAlreadyExists {
+		t.Errorf("expected a running operation id to be rejected, got: %v", err)
+	}
+	a.Cancel("op")
+	select {
+	case err := <-fetchErr:
+		if err == nil || json.Unmarshal([]byte(err.Error()), &got) != nil || got.Code != api.CodeCanceled {
+			t.Errorf("expected the first fetch to be canceled, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the first fetch wasn't canceled")
+	}
+	close(release)
+}
@@ -0,0 +1,111 @@
+package e2e
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	"github.com/google/uuid"
+)
+
+func TestTags_RenameMergeRemoveRewriteEvents(t *testing.T) {
+	const calendarName = "test-tags"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	for _, tag := range []core.Tag{{Name: "meeting", Color: "#36f"}, {Name: "meetnig"}, {Name: "call", Icon: "📞"}} {
+		if err := c.CreateTag(calendarName, tag); err != nil {
+			t.Fatalf("failed to create tag '%s': %v", tag.Name, err)
+		}
+	}
+	if err := c.CreateTag(calendarName, core.Tag{Name: "call"}); !errors.Is(err, core.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for a duplicate tag, got %v", err)
+	}
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	categories := [][]string{{"meeting"}, {"meetnig"}, {"meeting", "meetnig", "call"}, {"call"}, nil}
+	ids := make([]uuid.UUID, len(categories))
+	for i := range categories {
+		event := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "Tagged", Categories: categories[i], From: date.AddDate(0, 0, i), To: date.AddDate(0, 0, i).Add(time.Hour)}
+		if _, err := c.CreateEvent(event); err != nil {
+			t.Fatalf("failed to create an event: %v", err)
+		}
+		ids[i] = event.Id
+	}
+
+	unknown := core.Event{Id: uuid.New(), Calendar: calendarName, Title: "Typo", Categories: []string{"call", "meting"}, From: date, To: date.Add(time.Hour)}
+	if _, err := c.CreateEvent(unknown); !errors.Is(err, core.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent for an unknown tag, got %v", err)
+	}
+
+	commits := countCommits(t, repoPathOf(t, calendarName))
+
+	if err := c.MergeTags(calendarName, []string{"meetnig"}, "meeting"); err != nil {
+		t.Fatalf("failed to merge tags: %v", err)
+	}
+	if err := c.RenameTag(calendarName, "meeting", "work"); err != nil {
+		t.Fatalf("failed to rename tag: %v", err)
+	}
+	if err := c.RemoveTag(calendarName, "call"); err != nil {
+		t.Fatalf("failed to remove tag: %v", err)
+	}
+	if n := countCommits(t, repoPathOf(t, calendarName)); n != commits+3 {
+		t.Errorf("expected a single commit per tag change, got %d new commits", n-commits)
+	}
+
+	// reloaded from disk
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	tags, err := c2.ListTags(calendarName)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if want := []core.Tag{{Name: "work", Color: "#36f"}}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected tags %+v, got %+v", want, tags)
+	}
+	expected := [][]string{{"work"}, {"work"}, {"work"}, nil, nil}
+	for i, want := range expected {
+		event, err := c2.GetEvent(ids[i])
+		if err != nil {
+			t.Fatalf("failed to get event: %v", err)
+		}
+		if !reflect.DeepEqual(event.Categories, want) {
+			t.Errorf("event with categories %v should have %v, got %v", categories[i], want, event.Categories)
+		}
+	}
+}
+
+func TestTags_UnknownTagOrCalendar(t *testing.T) {
+	const calendarName = "test-tags-unknown"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	if err := c.CreateTag(calendarName, core.Tag{Name: "work"}); err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+
+	if err := c.UpdateTag(calendarName, core.Tag{Name: "home"}); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound updating an unknown tag, got %v", err)
+	}
+	if err := c.RenameTag(calendarName, "work", "work"); !errors.Is(err, core.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists renaming onto an existing tag, got %v", err)
+	}
+	if err := c.MergeTags(calendarName, []string{"home"}, "work"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound merging an unknown tag, got %v", err)
+	}
+	if err := c.CreateTag(calendarName, core.Tag{Name: "bad", Color: "blue"}); err == nil {
+		t.Error("expected an error for an invalid tag color")
+	}
+	if _, err := c.ListTags("test-tags-missing"); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown calendar, got %v", err)
+	}
+}
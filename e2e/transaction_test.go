@@ -0,0 +1,169 @@
+package e2e
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/git-calendar/core/pkg/core"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/google/uuid"
+)
+
+func TestCreateEvent_RollsBackOnFailure(t *testing.T) {
+	c := core.NewCore()
+
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	eventIn := core.Event{
+		Id:       uuid.New(),
+		Calendar: "this-calendar-does-not-exist",
+		Title:    "Ghost Event",
+		From:     date,
+		To:       date.Add(time.Hour),
+	}
+	if _, err := c.CreateEvent(eventIn); !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound when creating an event in a nonexistent calendar, got: %v", err)
+	}
+
+	if _, err := c.GetEvent(eventIn.Id); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("event stayed in memory after a failed create: %v", err)
+	}
+	for _, e := range c.GetEvents(date.Add(-time.Hour), date.Add(2*time.Hour)) {
+		if e.Id == eventIn.Id {
+			t.Errorf("event stayed in the index tree after a failed create")
+		}
+	}
+
+	// the id must be usable again
+	if _, err := c.CreateEvent(eventIn); errors.Is(err, core.ErrAlreadyExists) {
+		t.Errorf("failed create left the id taken: %v", err)
+	}
+}
+
+func TestUpdateFollowing_RollsBackParentOnFailure(t *testing.T) {
+	const calendarName = "test-transaction"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+
+	err := c.CreateCalendar(calendarName, "")
+	if err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	const count = 5
+	startTime := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	parentEvent := core.Event{
+		Id:       uuid.New(),
+		Calendar: calendarName,
+		Title:    "Daily Standup",
+		From:     startTime,
+		To:       startTime.Add(time.Hour),
+		Repeat: &core.Repetition{
+			Frequency: core.Day,
+			Interval:  1,
+			Count:     count,
+		},
+	}
+	if _, err = c.CreateEvent(parentEvent); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+
+	children := eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+5)), calendarName)
+	if len(children) != count {
+		t.Fatalf("setup: expected %d events, got %d", count, len(children))
+	}
+
+	// the new parent can't be saved, so the cap of the original parent must be undone
+	old := children[2]
+	updated := old
+	updated.Title = "Moved Standup"
+	updated.Calendar = "this-calendar-does-not-exist"
+	if _, err = c.UpdateRepeatingEvent(old, updated, core.Following); !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound when moving the series into a nonexistent calendar, got: %v", err)
+	}
+
+	parent, err := c.GetEvent(parentEvent.Id)
+	if err != nil {
+		t.Fatalf("failed to get parent event: %v", err)
+	}
+	if parent.Repeat == nil || parent.Repeat.Count != count || !parent.Repeat.Until.IsZero() {
+		t.Errorf("parent repeat was not rolled back: %+v", parent.Repeat)
+	}
+
+	children = eventsOfCalendar(c.GetEvents(startTime, startTime.AddDate(0, 0, count+5)), calendarName)
+	if len(children) != count {
+		t.Errorf("expected %d events after rollback, got %d", count, len(children))
+	}
+
+	// the state on disk must match the state in memory
+	c2 := core.NewCore()
+	if err := c2.LoadCalendars(); err != nil {
+		t.Fatalf("failed to load calendars: %v", err)
+	}
+	reloaded, err := c2.GetEvent(parentEvent.Id)
+	if err != nil {
+		t.Fatalf("failed to get parent event after reload: %v", err)
+	}
+	if reloaded.Repeat == nil || reloaded.Repeat.Count != count || !reloaded.Repeat.Until.IsZero() {
+		t.Errorf("parent repeat on disk was not rolled back: %+v", reloaded.Repeat)
+	}
+}
+
+func TestBatch_RollbackKeepsUntrackedFileUnstaged(t *testing.T) {
+	const calendarName = "test-transaction-untracked"
+	c := core.NewCore()
+	_ = c.RemoveCalendar(calendarName)
+	if err := c.CreateCalendar(calendarName, ""); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+	date := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := c.CreateEvent(core.Event{Calendar: calendarName, Title: "Committed", From: date, To: date.Add(time.Hour)}); err != nil {
+		t.Fatalf("failed to create an event: %v", err)
+	}
+
+	// a file the Core doesn't know about (e.g. copied in by the user)
+	id := uuid.New()
+	relPath := core.EventsDirName + "/" + id.String() + ".json"
+	content := `{"title": "Untracked", "from": "2026-01-01T09:00:00Z", "to": "2026-01-01T10:00:00Z", "calendar": "` + calendarName + `"}`
+	filePath := filepath.Join(repoPathOf(t, calendarName), filepath.FromSlash(relPath))
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	errAbort := errors.New("abort")
+	err := c.Batch(func(tx *core.Tx) error {
+		if _, err := tx.CreateEvent(core.Event{Id: id, Calendar: calendarName, Title: "Overwritten", From: date, To: date.Add(time.Hour)}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the batch to fail with errAbort, got: %v", err)
+	}
+
+	if raw, err := os.ReadFile(filePath); err != nil || string(raw) != content {
+		t.Errorf("the file content was not restored: %s (err: %v)", raw, err)
+	}
+	status := worktreeStatus(t, repoPathOf(t, calendarName))
+	if s := status.File(relPath); s.Staging != gogit.Untracked {
+		t.Errorf("the untracked file should stay unstaged after a rollback: %c%c", s.Staging, s.Worktree)
+	}
+	for file, s := range status {
+		if file != relPath && (s.Staging != gogit.Unmodified || s.Worktree != gogit.Unmodified) {
+			t.Errorf("file %s left modified after rollback: %c%c", file, s.Staging, s.Worktree)
+		}
+	}
+}
+
+// Helper
+func eventsOfCalendar(events []core.Event, name string) []core.Event {
+	result := []core.Event{}
+	for _, e := range events {
+		if e.Calendar == name {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -2,6 +2,7 @@
 // It's not possible to expose any "complex" data types (structs*, arrays, channels, maps, etc.),
 // because they do not have bindings to other languages.
 // Let's use JSON everywhere as a REST API would...
+// Errors too: every returned error is an *Error, whose message is a JSON object with a stable code.
 //
 // (*) You can return a *Event (pointer to struct), but you cannot receive it as argument.
 package api
@@ -10,10 +11,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/url"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/git-calendar/core/pkg/core"
+	"github.com/git-calendar/core/pkg/encryption"
 	"github.com/google/uuid"
 )
 
@@ -24,7 +27,8 @@ const (
 
 // The exposed/exported JSON-only API interface.
 type Api struct {
-	inner *core.Core
+	inner      *core.Core
+	operations sync.Map // operation id -> *operation, the running remote operations, see Cancel
 }
 
 // A "constructor" for the JSON API.
@@ -34,43 +38,95 @@ func NewApi() *Api {
 	}
 }
 
+// A "constructor" for the JSON API with custom storage location (e.g. an app-private directory on Android),
+// commit author and CORS proxy. Empty arguments mean the defaults.
+func NewApiWithOptions(rootPath, authorName, authorEmail, proxyUrl string) (*Api, error) {
+	inner, err := core.NewCoreWithOptions(core.Options{
+		RootPath:    rootPath,
+		AuthorName:  authorName,
+		AuthorEmail: authorEmail,
+		ProxyUrl:    proxyUrl,
+	})
+	if err != nil {
+		return nil, toApiError(err)
+	}
+	return &Api{inner: inner}, nil
+}
+
 // -------------------------- Boring methods that do not need any json parsing etc. -------------------------
 
-// func (a *Api) AddRemote(name, remoteUrl string) error { return a.inner.AddRemote(name, remoteUrl) }
 func (a *Api) CreateCalendar(name, password string) error {
-	return a.inner.CreateCalendar(name, password)
+	return toApiError(a.inner.CreateCalendar(name, password))
+}
+func (a *Api) RenameCalendar(oldName, newName string) error {
+	return toApiError(a.inner.RenameCalendar(oldName, newName))
+}
+func (a *Api) DuplicateCalendar(source, newName string) error {
+	return toApiError(a.inner.DuplicateCalendar(source, newName))
+}
+func (a *Api) RemoveCalendar(name string) error   { return toApiError(a.inner.RemoveCalendar(name)) }
+func (a *Api) SetCorsProxy(proxyUrl string) error { return toApiError(a.inner.SetCorsProxy(proxyUrl)) }
+func (a *Api) LoadCalendars() error               { return toApiError(a.inner.LoadCalendars()) }
+func (a *Api) LockCalendar(name string) error     { return toApiError(a.inner.LockCalendar(name)) }
+func (a *Api) UnlockCalendar(name, password string) error {
+	return toApiError(a.inner.UnlockCalendar(name, password))
+}
+func (a *Api) IsCalendarLocked(name string) (bool, error) {
+	locked, err := a.inner.IsCalendarLocked(name)
+	return locked, toApiError(err)
+}
+func (a *Api) SetAutoLock(seconds int) { a.inner.SetAutoLock(time.Duration(seconds) * time.Second) }
+func (a *Api) SetPrivateMode(name string, enabled bool) error {
+	return toApiError(a.inner.SetPrivateMode(name, enabled))
+}
+func (a *Api) RemoveQuarantined(calendar, path string) error {
+	return toApiError(a.inner.RemoveQuarantined(calendar, path))
+}
+func (a *Api) SetEncryptionMode(name string, mode int) error {
+	return toApiError(a.inner.SetEncryptionMode(name, encryption.Mode(mode)))
 }
-func (a *Api) RemoveCalendar(name string) error   { return a.inner.RemoveCalendar(name) }
-func (a *Api) SetCorsProxy(proxyUrl string) error { return a.inner.SetCorsProxy(proxyUrl) }
-func (a *Api) LoadCalendars() error               { return a.inner.LoadCalendars() }
-func (a *Api) PullAll() error                     { return a.inner.PullAll() }
-func (a *Api) PushAll() error                     { return a.inner.PushAll() }
 
 // ------------------------------  Wrapper methods encoding and decoding JSONs ------------------------------
 
 func (a *Api) CloneCalendar(repoUrl, password string) error {
-	parsedUrl, err := url.Parse(repoUrl)
+	parsedUrl, err := core.ParseRemoteUrl(repoUrl)
 	if err != nil {
-		return fmt.Errorf("repoUrl is invalid: %w", err)
+		return toApiError(fmt.Errorf("%w: repoUrl is invalid: %w", errInvalidArgument, err))
 	}
-	return a.inner.CloneCalendar(parsedUrl, password)
+	return toApiError(a.inner.CloneCalendar(parsedUrl, password))
+}
+
+func (a *Api) SetPlaintextFields(name, fieldsJson string) error {
+	var fields []string
+	if err := json.Unmarshal([]byte(fieldsJson), &fields); err != nil {
+		return toApiError(fmt.Errorf("%w: failed to unmarshal fields: %w", errInvalidArgument, err))
+	}
+	return toApiError(a.inner.SetPlaintextFields(name, fields))
 }
 
 func (a *Api) ListCalendars() (string, error) {
 	arr := a.inner.ListCalendars()
 	data, err := json.Marshal(arr)
 	if err != nil {
-		return emptyJsonArr, fmt.Errorf("failed to marshal names to json: %w", err)
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal calendars to json: %w", err))
 	}
 	return string(data), nil
 }
 
+func (a *Api) UpdateCalendarConfig(name, configJson string) error {
+	var cfg core.CalendarConfig
+	if err := json.Unmarshal([]byte(configJson), &cfg); err != nil {
+		return toApiError(fmt.Errorf("%w: failed to unmarshal calendar config: %w", errInvalidArgument, err))
+	}
+	return toApiError(a.inner.UpdateCalendarConfig(name, cfg))
+}
+
 func (a *Api) CreateEvent(eventJson string) (string, error) {
-	return returnJsonEventAndError(eventJson, a.inner.CreateEvent)
+	return returnJsonEventAndError(a.inner.Logger(), eventJson, a.inner.CreateEvent)
 }
 
 func (a *Api) UpdateEvent(eventJson string) (string, error) {
-	return returnJsonEventAndError(eventJson, a.inner.UpdateEvent)
+	return returnJsonEventAndError(a.inner.Logger(), eventJson, a.inner.UpdateEvent)
 }
 
 func (a *Api) UpdateRepeatingEvent(oldEventJson, newEventJson string, strategy int) (string, error) {
@@ -78,62 +134,79 @@ func (a *Api) UpdateRepeatingEvent(oldEventJson, newEventJson string, strategy i
 	var newEvent core.Event
 
 	if err := json.Unmarshal([]byte(oldEventJson), &oldEvent); err != nil {
-		fmt.Printf("CalendarCore got:\nNew: %s\nOld: %s\n", oldEventJson, newEventJson)
-		return emptyJson, fmt.Errorf("failed to unmarshal event data: %w", err)
+		a.inner.Logger().Debug("invalid input", "op", "update_repeating_event", "old", oldEventJson, "new", newEventJson, "error", err)
+		return emptyJson, toApiError(fmt.Errorf("%w: failed to unmarshal event data: %w", errInvalidArgument, err))
 	}
 
 	if err := json.Unmarshal([]byte(newEventJson), &newEvent); err != nil {
-		fmt.Printf("CalendarCore got:\nNew: %s\nOld: %s\n", oldEventJson, newEventJson)
-		return emptyJson, fmt.Errorf("failed to unmarshal event data: %w", err)
+		a.inner.Logger().Debug("invalid input", "op", "update_repeating_event", "old", oldEventJson, "new", newEventJson, "error", err)
+		return emptyJson, toApiError(fmt.Errorf("%w: failed to unmarshal event data: %w", errInvalidArgument, err))
 	}
 
 	updatedEvent, err := a.inner.UpdateRepeatingEvent(oldEvent, newEvent, core.UpdateStrategy(strategy))
 	if err != nil {
-		fmt.Printf("CalendarCore got:\nNew: %s\nOld: %s\n", oldEventJson, newEventJson)
-		return emptyJson, err
+		a.inner.Logger().Debug("operation failed", "op", "update_repeating_event", "old", oldEventJson, "new", newEventJson, "error", err)
+		return emptyJson, toApiError(err)
 	}
 
 	jsonBytes, err := json.Marshal(updatedEvent)
 	if err != nil {
-		return emptyJson, err
+		return emptyJson, toApiError(err)
 	}
 
-	return string(jsonBytes), err
+	return string(jsonBytes), toApiError(err)
 }
 
 func (a *Api) RemoveEvent(eventJson string) error {
 	var event core.Event
 	err := json.Unmarshal([]byte(eventJson), &event)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal event data: %w", err)
+		return toApiError(fmt.Errorf("%w: failed to unmarshal event data: %w", errInvalidArgument, err))
 	}
-	return a.inner.RemoveEvent(event)
+	return toApiError(a.inner.RemoveEvent(event))
 }
 
 func (a *Api) RemoveRepeatingEvent(eventJson string, strategy int) error {
 	var event core.Event
 	err := json.Unmarshal([]byte(eventJson), &event)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal event data: %w", err)
+		return toApiError(fmt.Errorf("%w: failed to unmarshal event data: %w", errInvalidArgument, err))
 	}
-	return a.inner.RemoveRepeatingEvent(event, core.UpdateStrategy(strategy))
+	return toApiError(a.inner.RemoveRepeatingEvent(event, core.UpdateStrategy(strategy)))
+}
+
+func (a *Api) MoveEvent(id, targetCalendar string) (string, error) {
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		return emptyJson, toApiError(fmt.Errorf("%w: invalid event id: %w", errInvalidArgument, err))
+	}
+	event, err := a.inner.MoveEvent(parsedId, targetCalendar)
+	if err != nil {
+		return emptyJson, toApiError(err)
+	}
+
+	jsonBytes, err := json.Marshal(event)
+	if err != nil {
+		return emptyJson, toApiError(fmt.Errorf("failed to marshal event to json: %w", err))
+	}
+	return string(jsonBytes), nil
 }
 
 func (a *Api) GetEvent(id string) (string, error) {
 	parsedId, err := uuid.Parse(id)
 	if err != nil {
-		return emptyJson, fmt.Errorf("invalid event id: %w", err)
+		return emptyJson, toApiError(fmt.Errorf("%w: invalid event id: %w", errInvalidArgument, err))
 	}
 	// pass the id to inner api
 	event, err := a.inner.GetEvent(parsedId)
 	if err != nil {
-		return emptyJson, err
+		return emptyJson, toApiError(err)
 	}
 
 	// marshal to json
 	jsonBytes, err := json.Marshal(event)
 	if err != nil {
-		return emptyJson, fmt.Errorf("failed to marshal event to json: %w", err)
+		return emptyJson, toApiError(fmt.Errorf("failed to marshal event to json: %w", err))
 	}
 
 	return string(jsonBytes), nil
@@ -144,7 +217,7 @@ func (a *Api) GetEvents(from, to string) (string, error) {
 	f, err1 := time.Parse(time.RFC3339, from)
 	t, err2 := time.Parse(time.RFC3339, to)
 	if err := errors.Join(err1, err2); err != nil {
-		return emptyJsonArr, fmt.Errorf("invalid from/to parameter: %w", err)
+		return emptyJsonArr, toApiError(fmt.Errorf("%w: invalid from/to parameter: %w", errInvalidArgument, err))
 	}
 
 	// pass the args to inner api
@@ -153,7 +226,82 @@ func (a *Api) GetEvents(from, to string) (string, error) {
 	// marshal to json
 	jsonBytes, err := json.Marshal(events)
 	if err != nil {
-		return emptyJsonArr, fmt.Errorf("failed to marshal events to json: %w", err)
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal events to json: %w", err))
+	}
+
+	return string(jsonBytes), nil
+}
+
+// Same as GetEvents, but returns only the events with any (or all, if matchAll) of the categories in the JSON array categoriesJson.
+func (a *Api) GetEventsByCategories(from, to, categoriesJson string, matchAll bool) (string, error) {
+	f, err1 := time.Parse(time.RFC3339, from)
+	t, err2 := time.Parse(time.RFC3339, to)
+	if err := errors.Join(err1, err2); err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("%w: invalid from/to parameter: %w", errInvalidArgument, err))
+	}
+	var categories []string
+	if err := json.Unmarshal([]byte(categoriesJson), &categories); err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("%w: failed to unmarshal categories: %w", errInvalidArgument, err))
+	}
+
+	jsonBytes, err := json.Marshal(a.inner.GetEventsByCategories(f, t, categories, matchAll))
+	if err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal events to json: %w", err))
+	}
+	return string(jsonBytes), nil
+}
+
+func (a *Api) ListQuarantine() (string, error) {
+	data, err := json.Marshal(a.inner.ListQuarantine())
+	if err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal quarantine to json: %w", err))
+	}
+	return string(data), nil
+}
+
+func (a *Api) ResolveQuarantined(calendar, path, eventJson string) (string, error) {
+	return returnJsonEventAndError(a.inner.Logger(), eventJson, func(event core.Event) (*core.Event, error) {
+		return a.inner.ResolveQuarantined(calendar, path, event)
+	})
+}
+
+func (a *Api) CheckIntegrity() (string, error) {
+	return returnJsonReportAndError(a.inner.CheckIntegrity)
+}
+
+func (a *Api) Repair() (string, error) {
+	return returnJsonReportAndError(a.inner.Repair)
+}
+
+// Applies a JSON array of operations (see core.BatchOp) as one batch, e.g.
+//
+//	[{"op": "create", "event": {...}}, {"op": "updateRepeating", "old": {...}, "event": {...}, "strategy": 1}]
+//
+// Returns a JSON array with the resulting event of each operation (null for removals).
+func (a *Api) ApplyBatch(opsJson string) (string, error) {
+	var ops []core.BatchOp
+	if err := json.Unmarshal([]byte(opsJson), &ops); err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("%w: failed to unmarshal batch operations: %w", errInvalidArgument, err))
+	}
+
+	results := make([]*core.Event, len(ops))
+	err := a.inner.Batch(func(tx *core.Tx) error {
+		for i, op := range ops {
+			event, err := tx.Apply(op)
+			if err != nil {
+				return fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+			}
+			results[i] = event
+		}
+		return nil
+	})
+	if err != nil {
+		return emptyJsonArr, toApiError(err)
+	}
+
+	jsonBytes, err := json.Marshal(results)
+	if err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal batch results to json: %w", err))
 	}
 
 	return string(jsonBytes), nil
@@ -166,24 +314,39 @@ func (a *Api) GetEvents(from, to string) (string, error) {
 //  2. Calls the coreFunc
 //  3. Marshals event that came back to JSON
 //  4. Returns json
-func returnJsonEventAndError(eventJson string, coreFunc func(core.Event) (*core.Event, error)) (string, error) {
+func returnJsonEventAndError(logger *slog.Logger, eventJson string, coreFunc func(core.Event) (*core.Event, error)) (string, error) {
 	var event core.Event
 	err := json.Unmarshal([]byte(eventJson), &event)
 	if err != nil {
-		fmt.Println("CalendarCore got: ", eventJson)
-		return emptyJson, fmt.Errorf("failed to unmarshal event data: %w", err)
+		logger.Debug("invalid input", "event", eventJson, "error", err)
+		return emptyJson, toApiError(fmt.Errorf("%w: failed to unmarshal event data: %w", errInvalidArgument, err))
 	}
 
 	newEvent, err := coreFunc(event)
 	if err != nil {
-		fmt.Println("CalendarCore got: ", eventJson)
-		return emptyJson, err
+		logger.Debug("operation failed", "event", eventJson, "error", err)
+		return emptyJson, toApiError(err)
 	}
 
 	jsonBytes, err := json.Marshal(newEvent)
 	if err != nil {
-		return emptyJson, err
+		return emptyJson, toApiError(err)
 	}
 
-	return string(jsonBytes), err
+	return string(jsonBytes), toApiError(err)
+}
+
+// A helper which runs the integrity check/repair and marshals the report to JSON.
+func returnJsonReportAndError(coreFunc func() (*core.IntegrityReport, error)) (string, error) {
+	report, err := coreFunc()
+	if err != nil {
+		return emptyJson, toApiError(err)
+	}
+
+	jsonBytes, err := json.Marshal(report)
+	if err != nil {
+		return emptyJson, toApiError(fmt.Errorf("failed to marshal report to json: %w", err))
+	}
+
+	return string(jsonBytes), nil
 }
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Gets told which calendar was changed, right after the change is committed.
+// Use it to tell other processes (or browser tabs) sharing the storage to call ReloadChanged. It must not block.
+type ChangeNotifier interface {
+	Notify(calendar string)
+}
+
+// Sets the notifier called after every change of a calendar. Nil disables the notifications.
+func (a *Api) SetChangeNotifier(notifier ChangeNotifier) {
+	if notifier == nil {
+		a.inner.SetChangeNotifier(nil)
+		return
+	}
+	a.inner.SetChangeNotifier(notifier)
+}
+
+// Reloads the calendars if another instance changed them. Returns a JSON array of the changed calendar names.
+func (a *Api) ReloadChanged() (string, error) {
+	changed, err := a.inner.ReloadChanged()
+	if err != nil {
+		return emptyJsonArr, toApiError(err)
+	}
+	data, err := json.Marshal(changed)
+	if err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal calendar names to json: %w", err))
+	}
+	return string(data), nil
+}
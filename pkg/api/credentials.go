@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/git-calendar/core/pkg/core"
+)
+
+// Supplies the credentials for git remotes (from a keychain, a login dialog...). The core never stores them.
+type CredentialProvider interface {
+	// Returns a JSON object like {"username": "...", "password": "..."} or {"token": "..."} (see the Credentials DTO),
+	// or an empty string for anonymous access.
+	Credentials(calendar, remoteName, remoteUrl string) (string, error)
+}
+
+// Sets the provider asked for the credentials before every clone, pull and push. Nil means anonymous access.
+func (a *Api) SetCredentialProvider(provider CredentialProvider) {
+	if provider == nil {
+		a.inner.SetCredentialProvider(nil)
+		return
+	}
+	a.inner.SetCredentialProvider(jsonCredentialProvider{provider})
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Adapts the JSON CredentialProvider to core.CredentialProvider.
+type jsonCredentialProvider struct {
+	provider CredentialProvider
+}
+
+func (p jsonCredentialProvider) Credentials(calendar, remoteName, remoteUrl string) (*core.Credentials, error) {
+	credsJson, err := p.provider.Credentials(calendar, remoteName, remoteUrl)
+	if err != nil || credsJson == "" {
+		return nil, err
+	}
+	var creds core.Credentials
+	if err := json.Unmarshal([]byte(credsJson), &creds); err != nil {
+		return nil, fmt.Errorf("%w: failed to unmarshal credentials: %w", errInvalidArgument, err)
+	}
+	return &creds, nil
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/git-calendar/core/pkg/core"
+)
+
+// Stable error codes, so that the clients don't have to match error messages.
+type ErrorCode string
+
+const (
+	CodeInternal        ErrorCode = "internal" // anything else
+	CodeInvalidArgument ErrorCode = "invalid_argument"
+	CodeNotFound        ErrorCode = "not_found"
+	CodeAlreadyExists   ErrorCode = "already_exists"
+	CodeInvalidEvent    ErrorCode = "invalid_event"
+	CodeWrongPassword   ErrorCode = "wrong_password"
+	CodeLocked          ErrorCode = "locked"
+	CodeConflict        ErrorCode = "conflict"
+	CodeNetwork         ErrorCode = "network"
+	CodeAuthFailed      ErrorCode = "auth_failed"
+	CodeReadOnly        ErrorCode = "read_only"
+	CodeBusy            ErrorCode = "busy"
+	CodeUncommitted     ErrorCode = "uncommitted"
+	CodeCanceled        ErrorCode = "canceled" // the operation was cancelled by Cancel
+)
+
+// The error returned by every Api method.
+// Its Error() is a JSON object like {"code": "not_found", "message": "event '...': not found"},
+// because gomobile only passes the error message to Kotlin/Swift.
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func (e *Error) Error() string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
+
+// The core errors and their codes. The first match wins.
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{errInvalidArgument, CodeInvalidArgument},
+	{context.Canceled, CodeCanceled},
+	{core.ErrWrongPassword, CodeWrongPassword},
+	{core.ErrLocked, CodeLocked},
+	{core.ErrInvalidEvent, CodeInvalidEvent},
+	{core.ErrNotFound, CodeNotFound},
+	{core.ErrAlreadyExists, CodeAlreadyExists},
+	{core.ErrConflict, CodeConflict},
+	{core.ErrAuthFailed, CodeAuthFailed},
+	{core.ErrNetwork, CodeNetwork},
+	{core.ErrReadOnly, CodeReadOnly},
+	{core.ErrBusy, CodeBusy},
+	{core.ErrUncommitted, CodeUncommitted},
+}
+
+// Returned when the input (JSON, id, time...) can't be parsed.
+var errInvalidArgument = errors.New("invalid argument")
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Converts any error to *Error with the matching code. Nil stays nil.
+func toApiError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	code := CodeInternal
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			code = ec.code
+			break
+		}
+	}
+	return &Error{Code: code, Message: err.Error()}
+}
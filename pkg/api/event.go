@@ -11,9 +11,11 @@ type Event struct {
 	From        string      `json:"from"` // RFC3339 format e.g., 2009-11-10T23:00:00Z (the default format of json.Marshal() for time.Time)
 	To          string      `json:"to"`   // RFC3339 format e.g., 2009-11-10T23:00:00Z (the default format of json.Marshal() for time.Time)
 	Calendar    string      `json:"calendar"`
-	Tag         string      `json:"tag"`
+	Categories  []string    `json:"categories"` // names of the calendar tags
 	ParentId    string      `json:"parentId"`
 	Repeat      *Repetition `json:"repeat"`
+
+	DetachedFrom string `json:"detached_from"` // the parent of the series the event was detached from
 }
 
 type Repetition struct {
@@ -23,3 +25,60 @@ type Repetition struct {
 	Count      int      `json:"count"`
 	Exceptions []string `json:"exceptions"`
 }
+
+// The shape of a calendar in ListCalendars.
+type CalendarInfo struct {
+	Name      string          `json:"name"`
+	Config    *CalendarConfig `json:"config"`
+	Encrypted bool            `json:"encrypted"`
+	Locked    bool            `json:"locked"`
+}
+
+// The shape of the calendar config (ListCalendars, UpdateCalendarConfig).
+type CalendarConfig struct {
+	DisplayName     string `json:"display_name"`
+	Color           string `json:"color"` // e.g. "#3366ff"
+	Description     string `json:"description"`
+	Timezone        string `json:"timezone"`         // IANA name, e.g. "Europe/Prague"
+	DefaultReminder int    `json:"default_reminder"` // minutes before the start
+	Tags            []*Tag `json:"tags"`
+}
+
+// The shape of a remote in ListRemotes.
+type Remote struct {
+	Name string   `json:"name"`
+	URLs []string `json:"urls"` // passwords are masked
+}
+
+// The shape of the credentials returned by a CredentialProvider. For http(s) either the username and password
+// (or an access token as the password), or a bearer token. For ssh (not in the browser) the private key.
+type Credentials struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"` // for ssh the passphrase of the private key
+	Token      string `json:"token"`
+	PrivateKey string `json:"private_key"` // PEM
+	KnownHosts string `json:"known_hosts"` // known_hosts lines verifying the ssh server
+}
+
+// The shape of the progress passed to a ProgressListener.
+type Progress struct {
+	Stage   string `json:"stage"` // e.g. "Counting objects", "Compressing objects"
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+}
+
+// The shape of the result of syncing a calendar with a remote (PullAll, PushAll).
+type SyncResult struct {
+	Calendar string `json:"calendar"`
+	Remote   string `json:"remote"`
+	Status   string `json:"status"`         // "ok", "up_to_date", "rejected" (the remote has diverged), "auth_failed", "network" or "failed"
+	Error    string `json:"error,omitzero"` // the error message, if the sync failed
+}
+
+// The shape of a calendar tag (ListTags, CreateTag, UpdateTag).
+type Tag struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"` // e.g. "#3366ff"
+	Icon        string `json:"icon"`  // an emoji or an icon name
+	Description string `json:"description"`
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Receives log records of the core, one JSON object per call, e.g.
+//
+//	{"time": "...", "level": "WARN", "msg": "quarantined event file", "op": "load", "calendar": "work", ...}
+//
+// Implement it in Kotlin/Swift to forward the logs to Logcat/OSLog.
+type LogSink interface {
+	Log(recordJson string)
+}
+
+// Sends the logs to the sink. Records below level are dropped (slog levels: -4 debug, 0 info, 4 warn, 8 error).
+// A nil sink resets the logging to the default (stderr).
+func (a *Api) SetLogSink(sink LogSink, level int) {
+	if sink == nil {
+		a.inner.SetLogger(nil)
+		return
+	}
+	handler := slog.NewJSONHandler(sinkWriter{sink}, &slog.HandlerOptions{Level: slog.Level(level)})
+	a.inner.SetLogger(slog.New(handler))
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// An io.Writer passing every write (the JSON handler writes a whole record at once) to the sink.
+type sinkWriter struct {
+	sink LogSink
+}
+
+func (w sinkWriter) Write(p []byte) (int, error) {
+	w.sink.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
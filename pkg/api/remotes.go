@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func (a *Api) AddRemote(calendar, remoteName, remoteUrl string) error {
+	return toApiError(a.inner.AddRemote(calendar, remoteName, remoteUrl))
+}
+
+// Returns a JSON array of the calendar's remotes (see the Remote DTO).
+func (a *Api) ListRemotes(calendar string) (string, error) {
+	remotes, err := a.inner.ListRemotes(calendar)
+	if err != nil {
+		return emptyJsonArr, toApiError(err)
+	}
+	data, err := json.Marshal(remotes)
+	if err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal remotes to json: %w", err))
+	}
+	return string(data), nil
+}
+
+func (a *Api) RemoveRemote(calendar, remoteName string) error {
+	return toApiError(a.inner.RemoveRemote(calendar, remoteName))
+}
+
+func (a *Api) RenameRemote(calendar, oldName, newName string) error {
+	return toApiError(a.inner.RenameRemote(calendar, oldName, newName))
+}
+
+func (a *Api) SetRemoteURL(calendar, remoteName, remoteUrl string) error {
+	return toApiError(a.inner.SetRemoteURL(calendar, remoteName, remoteUrl))
+}
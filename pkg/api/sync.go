@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/git-calendar/core/pkg/core"
+)
+
+// Receives the progress of a remote operation (Fetch, Pull, Push, CloneCalendarWithProgress).
+type ProgressListener interface {
+	// Gets a JSON object like {"stage": "Counting objects", "current": 9, "total": 20} (see the Progress DTO).
+	OnProgress(progressJson string)
+}
+
+// Downloads the commits of the remote ("" means origin) into the calendar repository.
+// The operation can be cancelled by Cancel(operationId); the id and the listener are optional.
+// An id can't be reused while its operation is running (fails with the "already_exists" code).
+func (a *Api) Fetch(operationId, calendar, remote string, listener ProgressListener) error {
+	ctx, done, err := a.startOperation(operationId, listener)
+	if err != nil {
+		return toApiError(err)
+	}
+	defer done()
+	return toApiError(a.inner.Fetch(ctx, calendar, remote))
+}
+
+// Fetches and merges the remote ("" means origin) into the calendar, see Fetch.
+func (a *Api) Pull(operationId, calendar, remote string, listener ProgressListener) error {
+	ctx, done, err := a.startOperation(operationId, listener)
+	if err != nil {
+		return toApiError(err)
+	}
+	defer done()
+	return toApiError(a.inner.Pull(ctx, calendar, remote))
+}
+
+// Uploads the commits of the calendar to the remote ("" means origin), merging it first if it has diverged, see Fetch.
+func (a *Api) Push(operationId, calendar, remote string, listener ProgressListener) error {
+	ctx, done, err := a.startOperation(operationId, listener)
+	if err != nil {
+		return toApiError(err)
+	}
+	defer done()
+	return toApiError(a.inner.Push(ctx, calendar, remote))
+}
+
+// Pulls every calendar with an origin remote. Returns a JSON array of the results per calendar (see the SyncResult DTO).
+// The error is only about reloading the pulled calendars.
+func (a *Api) PullAll() (string, error) {
+	results, err := a.inner.PullAll()
+	data, jsonErr := json.Marshal(results)
+	if jsonErr != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal sync results to json: %w", jsonErr))
+	}
+	return string(data), toApiError(err)
+}
+
+// Pushes every calendar to each of its remotes, merging the diverged ones first. Returns a JSON array of the results
+// per calendar and remote (see the SyncResult DTO). The error is only about reloading the merged calendars.
+func (a *Api) PushAll() (string, error) {
+	results, err := a.inner.PushAll()
+	data, jsonErr := json.Marshal(results)
+	if jsonErr != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal sync results to json: %w", jsonErr))
+	}
+	return string(data), toApiError(err)
+}
+
+// Same as CloneCalendar, but cancellable and reporting the progress, see Fetch.
+func (a *Api) CloneCalendarWithProgress(operationId, repoUrl, password string, listener ProgressListener) error {
+	parsedUrl, err := core.ParseRemoteUrl(repoUrl)
+	if err != nil {
+		return toApiError(fmt.Errorf("%w: repoUrl is invalid: %w", errInvalidArgument, err))
+	}
+	ctx, done, err := a.startOperation(operationId, listener)
+	if err != nil {
+		return toApiError(err)
+	}
+	defer done()
+	return toApiError(a.inner.CloneCalendarContext(ctx, parsedUrl, password))
+}
+
+// Cancels the running remote operation; it fails with the "canceled" code. Does nothing if it isn't running.
+func (a *Api) Cancel(operationId string) {
+	if op, ok := a.operations.LoadAndDelete(operationId); ok {
+		op.(*operation).cancel()
+	}
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// A running remote operation, see Cancel.
+type operation struct {
+	cancel context.CancelFunc
+}
+
+// Returns the context of a remote operation, registered under the id (if not empty) and reporting to the listener (if not nil).
+// The returned function must be called when the operation ends. Fails if another operation with the same id is running.
+func (a *Api) startOperation(operationId string, listener ProgressListener) (context.Context, func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if listener != nil {
+		ctx = core.WithProgress(ctx, func(progress core.Progress) {
+			data, err := json.Marshal(progress)
+			if err != nil {
+				return
+			}
+			listener.OnProgress(string(data))
+		})
+	}
+	op := &operation{cancel: cancel}
+	if operationId != "" {
+		if _, running := a.operations.LoadOrStore(operationId, op); running {
+			cancel()
+			return nil, nil, fmt.Errorf("%w: operation '%s' is already running", core.ErrAlreadyExists, operationId)
+		}
+	}
+
+	return ctx, func() {
+		if operationId != "" {
+			a.operations.CompareAndDelete(operationId, op) // the id may be reused after a Cancel
+		}
+		cancel()
+	}, nil
+}
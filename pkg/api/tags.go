@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/git-calendar/core/pkg/core"
+)
+
+// Returns a JSON array of the calendar's tags (see the Tag DTO).
+func (a *Api) ListTags(calendar string) (string, error) {
+	tags, err := a.inner.ListTags(calendar)
+	if err != nil {
+		return emptyJsonArr, toApiError(err)
+	}
+	if tags == nil {
+		tags = []core.Tag{}
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return emptyJsonArr, toApiError(fmt.Errorf("failed to marshal tags to json: %w", err))
+	}
+	return string(data), nil
+}
+
+func (a *Api) CreateTag(calendar, tagJson string) error {
+	tag, err := unmarshalTag(tagJson)
+	if err != nil {
+		return toApiError(err)
+	}
+	return toApiError(a.inner.CreateTag(calendar, tag))
+}
+
+func (a *Api) UpdateTag(calendar, tagJson string) error {
+	tag, err := unmarshalTag(tagJson)
+	if err != nil {
+		return toApiError(err)
+	}
+	return toApiError(a.inner.UpdateTag(calendar, tag))
+}
+
+func (a *Api) RemoveTag(calendar, name string) error {
+	return toApiError(a.inner.RemoveTag(calendar, name))
+}
+
+func (a *Api) RenameTag(calendar, oldName, newName string) error {
+	return toApiError(a.inner.RenameTag(calendar, oldName, newName))
+}
+
+// Merges the tags in the JSON array sourcesJson into the target tag.
+func (a *Api) MergeTags(calendar, sourcesJson, target string) error {
+	var sources []string
+	if err := json.Unmarshal([]byte(sourcesJson), &sources); err != nil {
+		return toApiError(fmt.Errorf("%w: failed to unmarshal tag names: %w", errInvalidArgument, err))
+	}
+	return toApiError(a.inner.MergeTags(calendar, sources, target))
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+func unmarshalTag(tagJson string) (core.Tag, error) {
+	var tag core.Tag
+	if err := json.Unmarshal([]byte(tagJson), &tag); err != nil {
+		return tag, fmt.Errorf("%w: failed to unmarshal tag: %w", errInvalidArgument, err)
+	}
+	return tag, nil
+}
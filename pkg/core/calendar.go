@@ -2,12 +2,18 @@ package core
 
 import (
 	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 )
 
 type Calendar struct {
 	Repository    *gogit.Repository
-	Tags          []string
+	Config        CalendarConfig // Display settings and the tags (ConfigFileName); empty while locked.
 	EncryptionKey []byte
+	Metadata      Metadata
+	Locked        bool              // Encrypted calendar whose key was wiped; its events are not loaded until unlocked.
+	Quarantine    []QuarantinedFile // Event files which couldn't be loaded.
+
+	head plumbing.Hash // HEAD matching the loaded events, see Core.ReloadChanged
 }
 
 func (cal *Calendar) IsEncrypted() bool {
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // the timezone database, browsers and some phones don't have one for Go
+
+	"github.com/git-calendar/core/pkg/encryption"
+	"github.com/go-git/go-billy/v5"
+)
+
+// The user-facing settings of a calendar.
+//
+// It is committed in the repository root (ConfigFileName), so it syncs like the events.
+// In an encrypted calendar the values are encrypted too, so a locked calendar has an empty config.
+type CalendarConfig struct {
+	DisplayName     string `json:"display_name,omitzero"` // Shown instead of the calendar (directory) name.
+	Color           string `json:"color,omitzero"`        // Hex color like "#3366ff" or "#36f".
+	Description     string `json:"description,omitzero"`
+	Timezone        string `json:"timezone,omitzero"`         // IANA name of the default timezone for new events, e.g. "Europe/Prague".
+	DefaultReminder int    `json:"default_reminder,omitzero"` // Minutes before the start of new events; 0 means no reminder.
+	Tags            []Tag  `json:"tags,omitzero"`             // The tags the events can use (if empty, any tag is allowed).
+}
+
+// A tag (category) of events in a calendar, see CalendarConfig.Tags.
+type Tag struct {
+	Name        string `json:"name"`
+	Color       string `json:"color,omitzero"` // Hex color like "#3366ff" or "#36f".
+	Icon        string `json:"icon,omitzero"`  // An emoji or an icon name known to the client.
+	Description string `json:"description,omitzero"`
+}
+
+// A calendar as listed by Core.ListCalendars.
+type CalendarInfo struct {
+	Name      string         `json:"name"`
+	Config    CalendarConfig `json:"config"`
+	Encrypted bool           `json:"encrypted"`
+	Locked    bool           `json:"locked"`
+}
+
+var colorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func (cfg CalendarConfig) Validate() error {
+	if cfg.Color != "" && !colorRegex.MatchString(cfg.Color) {
+		return fmt.Errorf("invalid color '%s'", cfg.Color)
+	}
+	if cfg.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone '%s'", cfg.Timezone)
+		}
+	}
+	if cfg.DefaultReminder < 0 {
+		return errors.New("default reminder cannot be negative")
+	}
+	for i, tag := range cfg.Tags {
+		if err := tag.Validate(); err != nil {
+			return err
+		}
+		if cfg.tagIndex(tag.Name) != i {
+			return fmt.Errorf("duplicate tag '%s'", tag.Name)
+		}
+	}
+	return nil
+}
+
+func (t Tag) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("tag name cannot be empty")
+	}
+	if t.Color != "" && !colorRegex.MatchString(t.Color) {
+		return fmt.Errorf("invalid color '%s' of tag '%s'", t.Color, t.Name)
+	}
+	return nil
+}
+
+// Also accepts a plain string (just the name), like the tags were stored at first.
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = Tag{Name: name}
+		return nil
+	}
+	type plainTag Tag // without this method
+	return json.Unmarshal(data, (*plainTag)(t))
+}
+
+// Returns the index of the tag with the name, or -1.
+func (cfg CalendarConfig) tagIndex(name string) int {
+	return slices.IndexFunc(cfg.Tags, func(t Tag) bool { return t.Name == name })
+}
+
+// Returns a deep copy of the config.
+func (cfg CalendarConfig) clone() CalendarConfig {
+	cfg.Tags = slices.Clone(cfg.Tags)
+	return cfg
+}
+
+// Loads the config into the calendar. A broken config is logged and replaced by the defaults, the events can be read without it.
+func (c *Core) loadCalendarConfig(name string, cal *Calendar) {
+	cal.Config = CalendarConfig{}
+	if cal.Locked {
+		return // encrypted
+	}
+	cfg, err := c.loadConfig(name, cal.EncryptionKey, cal.Metadata)
+	if err != nil {
+		c.logger.Warn("failed to load calendar config", "op", "load", "calendar", name, "error", err)
+		return
+	}
+	cal.Config = cfg
+}
+
+// Reads the config file of a calendar repository, decrypting it with the key if set. Returns the defaults if the file doesn't exist.
+func (c *Core) loadConfig(name string, key []byte, meta Metadata) (CalendarConfig, error) {
+	var cfg CalendarConfig
+
+	file, err := c.fs.Open(c.fs.Join(name, ConfigFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if len(key) != 0 {
+		var data map[string]any
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return cfg, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		decrypted, err := encryption.DecryptFieldsWithMode(data, key, []byte(ConfigFileName), meta.EncryptionMode)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to decrypt config file: %w", err)
+		}
+		if raw, err = json.Marshal(decrypted); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Writes the calendar config (encrypted, if the calendar is) into the repository and stages it. The caller is responsible for the commit.
+func (c *Core) stageConfig(name string) error {
+	cal, ok := c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	if cal.Locked {
+		return fmt.Errorf("calendar '%s': %w", name, ErrLocked)
+	}
+
+	var content any = cal.Config
+	if cal.IsEncrypted() {
+		raw, err := json.Marshal(cal.Config)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		var data map[string]any
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return err
+		}
+		if content, err = encryption.EncryptFieldsWithMode(data, cal.EncryptionKey, []byte(ConfigFileName), cal.Metadata.EncryptionMode); err != nil {
+			return fmt.Errorf("failed to encrypt config: %w", err)
+		}
+	}
+
+	raw, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	err = c.writeFileAtomic(c.fs.Join(name, ConfigFileName), func(file billy.File) error {
+		_, err := file.Write(raw)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	w, err := cal.Repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if _, err := w.Add(ConfigFileName); err != nil {
+		return fmt.Errorf("git add: %w", err)
+	}
+
+	return nil
+}
@@ -1,12 +1,26 @@
 package core
 
+import "time"
+
 const (
 	IndexFileName     string = "index.json"
 	RichIndexFileName string = "index-rich.json"
+	MetadataFileName  string = "metadata.json"
+	ConfigFileName    string = "calendar.json"
+
+	EventsDirName  string = "events"
+	TempFilePrefix string = ".tmp-" // prefix of files being written, see Core.writeFileAtomic
 
-	EventsDirName string = "events"
+	GitAuthorName        string = "git-calendar"
+	DefaultCommitMessage string = "Updated calendar"   // used for a commit without any described change
+	PrivateCommitMessage string = DefaultCommitMessage // used for every commit of a calendar in private mode
+)
+
+const (
+	RepoLeaseTTL    = 30 * time.Second // a repository lease older than this is considered abandoned (its owner crashed)
+	RepoLockTimeout = 5 * time.Second  // how long a write waits for another instance (or a sync) to release the repository
 
-	GitAuthorName string = "git-calendar"
+	MaxPushRetries = 3 // how many times a push rejected by a diverged remote is retried after merging it
 )
 
 // ------- Repeating frequency -------
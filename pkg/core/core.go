@@ -1,15 +1,23 @@
 package core
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/git-calendar/core/pkg/filesystem"
 	"github.com/go-git/go-billy/v5"
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	gogitfs "github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/google/uuid"
 )
@@ -17,80 +25,126 @@ import (
 // The real API.
 //
 // Works with raw Go structs, use api.Api to work with JSON.
+// It's safe for concurrent use: reads run in parallel, writes (of any calendar) are serialized and block reads.
+// The network part of a sync blocks only writes into the calendar being synced.
 type Core struct {
+	mu sync.RWMutex // guards everything below, except the atomics
+
 	intervalTree *IntervalTree
 	events       map[uuid.UUID]*Event
 	calendars    map[string]*Calendar
 	fs           billy.Filesystem // root "/" for OPFS, "$HOME" for classic FS
 	proxyUrl     *url.URL         // cors proxy, that works with "url" query param (like https://cors-proxy.abc/?url=https://github.com/...) (only needed for the browser!)
+
+	autoLockTimeout atomic.Int64 // idle time.Duration after which encrypted calendars get locked (0 = never)
+	lastActivity    atomic.Int64 // unix nanoseconds of the last call
+	autoLockMu      sync.Mutex   // guards autoLockTimer
+	autoLockTimer   *time.Timer  // locks the encrypted calendars when the Core is idle, reset by every call
+	authorName      string
+	authorEmail     string
+	logger          *slog.Logger       // diagnostics (unreadable files, inconsistent index...), records have "op", "calendar" and "event" fields
+	instanceId      string             // owner of the repository leases taken by this Core
+	repoMutexes     sync.Map           // calendar name -> *sync.Mutex, guards the repositories against other goroutines (see lockRepo)
+	clones          sync.Map           // calendar name -> struct{}, calendars being cloned, not loaded until registered (see cloneCalendar)
+	locker          RepoLocker         // guards the repositories against writes of other instances sharing the storage
+	notifier        ChangeNotifier     // tells other instances about changes, may be nil
+	credentials     CredentialProvider // asked for the auth of git remotes, may be nil
 	// tags      map[string][]string // might not be needed to "cache" it like this
 }
 
-// A "constructor" for Core.
+// A "constructor" for Core. Panics if the default filesystem can't be opened, use NewCoreWithOptions to handle the error.
 func NewCore() *Core {
+	c, err := NewCoreWithOptions(Options{})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Options for NewCoreWithOptions. Zero values mean the defaults (the same as NewCore uses).
+type Options struct {
+	Filesystem  billy.Filesystem // where the calendars are stored; takes precedence over RootPath
+	RootPath    string           // a directory (the IndexedDB store name in the browser) where the calendars are stored; default is filesystem.GetFS()
+	AuthorName  string           // the author of commits; default is GitAuthorName
+	AuthorEmail string
+	Logger      *slog.Logger       // default is slog.Default()
+	ProxyUrl    string             // see SetCorsProxy
+	Locker      RepoLocker         // default is the filesystem itself if it implements RepoLocker (IndexedDB), lock files otherwise
+	Notifier    ChangeNotifier     // see SetChangeNotifier
+	Credentials CredentialProvider // see SetCredentialProvider
+}
+
+// A "constructor" for Core with custom storage, commit author, logger and CORS proxy.
+func NewCoreWithOptions(opts Options) (*Core, error) {
 	var c Core
 	c.resetCore()
+	c.SetLogger(opts.Logger)
+
+	c.authorName = cmp.Or(opts.AuthorName, GitAuthorName)
+	c.authorEmail = opts.AuthorEmail
 
-	// get the fs; go tags handle which one (classic/wasm)
 	var err error
-	c.fs, err = filesystem.GetFS()
+	switch {
+	case opts.Filesystem != nil:
+		c.fs = opts.Filesystem
+	case opts.RootPath != "":
+		c.fs, err = filesystem.GetFSAt(opts.RootPath)
+	default:
+		c.fs, err = filesystem.GetFS() // go tags handle which one (classic/wasm)
+	}
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to open filesystem: %w", err)
 	}
 
-	return &c
+	if opts.ProxyUrl != "" {
+		if err := c.SetCorsProxy(opts.ProxyUrl); err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+	}
+
+	c.instanceId = uuid.NewString()
+	c.notifier = opts.Notifier
+	c.credentials = opts.Credentials
+	switch locker, ok := c.fs.(RepoLocker); {
+	case opts.Locker != nil:
+		c.locker = opts.Locker
+	case ok:
+		c.locker = locker
+	default:
+		c.locker = fileLocker{fs: c.fs}
+	}
+
+	return &c, nil
 }
 
 // Sets a url for CORS proxy. This is only needed inside a browser.
 func (c *Core) SetCorsProxy(proxyUrl string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	var err error
 	trimmed := strings.TrimSuffix(proxyUrl, "/") // remove trailing "/"
 	c.proxyUrl, err = url.ParseRequestURI(trimmed)
 	return err
 }
 
-// Update all remotes for all repositories.
-func (c *Core) PushAll() error {
-	var errs error
-	for _, cal := range c.calendars {
-		remotes, err := cal.Repository.Remotes()
-		if err != nil {
-			errs = errors.Join(err)
-		}
+// Sets where diagnostics are logged. Nil resets it to slog.Default().
+func (c *Core) SetLogger(logger *slog.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		for _, remote := range remotes {
-			err = remote.Push(&gogit.PushOptions{})
-			if err == gogit.NoErrAlreadyUpToDate {
-				continue // this is ok
-			}
-			if err != nil {
-				errs = errors.Join(err)
-			}
-		}
+	if logger == nil {
+		logger = slog.Default()
 	}
-	return errs
+	c.logger = logger
 }
 
-// Update all repositories from remotes.
-func (c *Core) PullAll() error {
-	// TODO idk if it works
-
-	var err error
-	for _, cal := range c.calendars {
-		wt, errx := cal.Repository.Worktree()
-		if errx != nil || wt == nil { // only fails if repo is bare (aka. only .git/ folder exists, no files) which should not happen ever haha
-			continue
-		}
+// Returns the logger used by the Core.
+func (c *Core) Logger() *slog.Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-		errx = wt.Pull(&gogit.PullOptions{})
-		if errx == gogit.NoErrAlreadyUpToDate {
-			continue // this is ok
-		}
-		if errx != nil {
-			err = errors.Join(errx)
-		}
-	}
-	return err
+	return c.logger
 }
 
 // ------------------------------------------------ Helpers -------------------------------------------------
@@ -102,6 +156,61 @@ func (c *Core) resetCore() {
 	c.calendars = make(map[string]*Calendar)
 }
 
+// Commits everything staged in the calendar repository with the given message. An empty commit is not an error.
+// Calendars in private mode get a generic message instead.
+func (c *Core) commit(cal *Calendar, commitMsg string) error {
+	w, err := cal.Repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	_, err = w.Commit(cal.Metadata.commitMessage(commitMsg), &gogit.CommitOptions{Author: c.signature()})
+	if err != nil && !errors.Is(err, gogit.ErrEmptyCommit) {
+		return fmt.Errorf("failed to git commit: %w", err)
+	}
+	cal.head = repoHead(cal.Repository)
+	return nil
+}
+
+// Returns the author of the commits.
+func (c *Core) signature() *object.Signature {
+	return &object.Signature{
+		Name:  c.authorName,
+		Email: c.authorEmail,
+		When:  time.Now(),
+	}
+}
+
+// Writes a file atomically. The content is written into a temporary file next to the target, which then replaces it.
+// A crash in between leaves either the old or the new content, never an empty or partial file.
+func (c *Core) writeFileAtomic(filePath string, write func(file billy.File) error) error {
+	tmpPath := c.fs.Join(filepath.Dir(filePath), TempFilePrefix+filepath.Base(filePath))
+
+	file, err := c.fs.Create(tmpPath)
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("failed to create temp file: %w: %w", ErrReadOnly, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		_ = c.fs.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		_ = c.fs.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := c.fs.Rename(tmpPath, filePath); err != nil {
+		_ = c.fs.Remove(tmpPath)
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
 // Loads, if exists, or creates new repository with the given name.
 func (c *Core) initCalendarRepo(name string) (*gogit.Repository, error) {
 	if err := c.fs.MkdirAll(name, 0o755); err != nil {
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// A batch of event changes, see Core.Batch. It is only valid inside the function passed to Core.Batch.
+type Tx struct {
+	c  *Core
+	tx *transaction
+}
+
+// Applies many event changes at once. All changes made through tx are committed together
+// (one commit per touched calendar with a summary message). If fn returns an error or any change fails,
+// nothing is committed and the whole batch is rolled back.
+//
+// The Core is locked while fn runs, so fn must not call other Core methods; use tx instead.
+func (c *Core) Batch(fn func(tx *Tx) error) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.transaction(func(tx *transaction) error {
+		return fn(&Tx{c: c, tx: tx})
+	})
+}
+
+// Same as Core.GetEvent, but inside the batch (it sees the changes made so far).
+func (t *Tx) GetEvent(id uuid.UUID) (*Event, error) {
+	e, ok := t.c.events[id]
+	if !ok {
+		return nil, fmt.Errorf("event '%s': %w", id, ErrNotFound)
+	}
+	event := e.clone()
+	return &event, nil
+}
+
+// Same as Core.CreateEvent, but inside the batch.
+func (t *Tx) CreateEvent(event Event) (*Event, error) {
+	return t.c.createEvent(t.tx, event)
+}
+
+// Same as Core.UpdateEvent, but inside the batch.
+func (t *Tx) UpdateEvent(event Event) (*Event, error) {
+	return t.c.updateEvent(t.tx, event)
+}
+
+// Same as Core.UpdateRepeatingEvent, but inside the batch.
+func (t *Tx) UpdateRepeatingEvent(old, new Event, strat UpdateStrategy) (*Event, error) {
+	return t.c.updateRepeatingEvent(t.tx, old, new, strat)
+}
+
+// Same as Core.RemoveEvent, but inside the batch.
+func (t *Tx) RemoveEvent(event Event) error {
+	return t.c.removeEvent(t.tx, event)
+}
+
+// Same as Core.RemoveRepeatingEvent, but inside the batch.
+func (t *Tx) RemoveRepeatingEvent(event Event, strat UpdateStrategy) error {
+	return t.c.removeRepeatingEvent(t.tx, event, strat)
+}
+
+// Kinds of operations in a batch (see BatchOp).
+type BatchOpKind string
+
+const (
+	OpCreate          BatchOpKind = "create"
+	OpUpdate          BatchOpKind = "update"
+	OpUpdateRepeating BatchOpKind = "updateRepeating"
+	OpRemove          BatchOpKind = "remove"
+	OpRemoveRepeating BatchOpKind = "removeRepeating"
+)
+
+// A single serializable batch operation. Old is only used by OpUpdateRepeating,
+// Strategy by OpUpdateRepeating and OpRemoveRepeating.
+type BatchOp struct {
+	Op       BatchOpKind    `json:"op"`
+	Event    Event          `json:"event"`
+	Old      *Event         `json:"old,omitempty"`
+	Strategy UpdateStrategy `json:"strategy,omitzero"`
+}
+
+// Applies the operation inside the batch. Returns the created/updated event (nil for removals).
+func (t *Tx) Apply(op BatchOp) (*Event, error) {
+	switch op.Op {
+	case OpCreate:
+		return t.CreateEvent(op.Event)
+	case OpUpdate:
+		return t.UpdateEvent(op.Event)
+	case OpUpdateRepeating:
+		if op.Old == nil {
+			return nil, fmt.Errorf("%w: missing old event", ErrInvalidEvent)
+		}
+		return t.UpdateRepeatingEvent(*op.Old, op.Event, op.Strategy)
+	case OpRemove:
+		return nil, t.RemoveEvent(op.Event)
+	case OpRemoveRepeating:
+		return nil, t.RemoveRepeatingEvent(op.Event, op.Strategy)
+	default:
+		return nil, fmt.Errorf("unknown batch operation '%s'", op.Op)
+	}
+}
@@ -1,62 +1,222 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"slices"
 	"strings"
 
 	"github.com/git-calendar/core/pkg/encryption"
+	"github.com/go-git/go-billy/v5"
 	gogitutil "github.com/go-git/go-billy/v5/util"
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	gogitfs "github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/google/uuid"
 )
 
 // Creates a new calendar.
 func (c *Core) CreateCalendar(name, password string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	repo, err := c.initCalendarRepo(name)
 	if err != nil {
 		return fmt.Errorf("failed to init calendar repo: %w", err)
 	}
 
+	meta, err := c.loadMetadata(name) // the repo might already exist
+	if err != nil {
+		return err
+	}
+
 	var key []byte = nil
 	if len(password) != 0 {
-		key = encryption.DeriveKey(password, []byte(name))
-
-		keyFile, err := c.fs.Create(fmt.Sprintf("%s.key", name))
-		if err != nil {
-			return fmt.Errorf("failed to create key file: %w", err)
+		key = encryption.DeriveKey(password, meta.keySalt(name))
+		if !meta.checkKey(key) {
+			return ErrWrongPassword
 		}
-		defer keyFile.Close()
-
-		if _, err = keyFile.Write(key); err != nil {
-			return fmt.Errorf("failed to write key to key file: %w", err)
+		if err := c.writeKeyFile(name, key); err != nil {
+			return err
 		}
+		_ = c.fs.Remove(lockFileName(name)) // the repo might have been locked before
 	}
 
-	c.calendars[name] = &Calendar{
+	cal := &Calendar{
 		Repository:    repo,
-		Tags:          []string{},
 		EncryptionKey: key,
+		Metadata:      meta,
+		Locked:        key == nil && c.isLockedOnDisk(name),
+		head:          repoHead(repo),
 	}
+	c.loadCalendarConfig(name, cal)
+	c.calendars[name] = cal
+
+	if key != nil && meta.KeyCheck == "" && cal.head.IsZero() { // a new calendar
+		if err := c.addKeyCheck(name); err != nil {
+			return err
+		}
+	}
+	c.notifyChange(name)
 	return nil
 }
 
-// Returns a list of calendar names loaded.
-func (c *Core) ListCalendars() []string {
-	// TODO list tags too
-	calendars := slices.Collect(maps.Keys(c.calendars))
-	slices.Sort(calendars)
+// Returns the loaded calendars with their configs, sorted by name.
+func (c *Core) ListCalendars() []CalendarInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	calendars := make([]CalendarInfo, 0, len(c.calendars))
+	for _, name := range slices.Sorted(maps.Keys(c.calendars)) {
+		cal := c.calendars[name]
+		calendars = append(calendars, CalendarInfo{
+			Name:      name,
+			Config:    cal.Config.clone(),
+			Encrypted: cal.IsEncrypted() || cal.Locked,
+			Locked:    cal.Locked,
+		})
+	}
 	return calendars
 }
 
 // Tries to load every directory/repo/calendar in the fs root.
 func (c *Core) LoadCalendars() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.loadCalendars()
+}
+
+// Clones a repository/calendar from url, using CORS proxy, if specified.
+func (c *Core) CloneCalendar(repoUrl *url.URL, password string) error {
+	return c.CloneCalendarContext(context.Background(), repoUrl, password)
+}
+
+// Same as CloneCalendar, but cancellable by the context, which may also report the progress (see WithProgress).
+// The Core isn't locked during the network part, the calendar appears once the clone is complete.
+func (c *Core) CloneCalendarContext(ctx context.Context, repoUrl *url.URL, password string) error {
+	return c.cloneCalendar(ctx, repoUrl, password)
+}
+
+// Removes and deletes the whole calendar.
+func (c *Core) RemoveCalendar(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.removeCalendar(name)
+}
+
+// Renames the calendar: moves its repository directory and key, and rewrites the Calendar field of all its events (in one commit).
+// The key of an encrypted calendar stays the same, the old name is kept in the metadata as the key salt.
+// A locked calendar has to be unlocked first.
+func (c *Core) RenameCalendar(oldName, newName string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.renameCalendar(oldName, newName)
+}
+
+// Forks the calendar into a new repository (without history). All events get new ids.
+// An encrypted copy is encrypted with the same key (it is unlocked with the same password).
+func (c *Core) DuplicateCalendar(source, newName string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.duplicateCalendar(source, newName)
+}
+
+// Changes how the values of an encrypted calendar are encrypted.
+// All events of the calendar are re-encrypted and committed together with the metadata in one commit.
+// Fails with ErrInvalidEvent while the calendar has quarantined files, they couldn't be re-encrypted.
+func (c *Core) SetEncryptionMode(name string, mode encryption.Mode) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateMetadata(name, "Changed encryption mode", func(meta *Metadata) {
+		meta.EncryptionMode = mode
+	})
+}
+
+// Sets which event fields (JSON names like "from", "to") stay unencrypted in an encrypted calendar.
+// All events of the calendar are re-encrypted and committed together with the metadata in one commit.
+// Fails with ErrInvalidEvent while the calendar has quarantined files, they couldn't be re-encrypted.
+func (c *Core) SetPlaintextFields(name string, fields []string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateMetadata(name, "Changed plaintext fields", func(meta *Metadata) {
+		meta.PlaintextFields = slices.Clone(fields)
+	})
+}
+
+// Turns the private mode of an encrypted calendar on or off.
+// In private mode all commit messages are generic and repeat exceptions are always encrypted.
+// Already existing commits are not rewritten.
+// File names don't leak the times either: only parents and detached events are stored and they have random ids,
+// the UUIDv8 ids of children (which encode the occurrence time) appear only in the encrypted exceptions.
+func (c *Core) SetPrivateMode(name string, enabled bool) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateMetadata(name, "Changed private mode", func(meta *Metadata) {
+		meta.Private = enabled
+	})
+}
+
+// Replaces the config of the calendar (display name, color, tags...) and commits it.
+func (c *Core) UpdateCalendarConfig(name string, cfg CalendarConfig) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cal, ok := c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	if cal.Locked {
+		return fmt.Errorf("calendar '%s': %w", name, ErrLocked)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if reflect.DeepEqual(cal.Config, cfg) {
+		return nil // nothing to do
+	}
+
+	return c.transaction(func(tx *transaction) error {
+		return tx.saveConfig(name, cfg.clone(), "Updated calendar config")
+	})
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// The implementations of the public methods above, without locking.
+
+func (c *Core) loadCalendars() error {
 	c.resetCore()
 
 	// load repositories
@@ -70,66 +230,58 @@ func (c *Core) LoadCalendars() error {
 			continue
 		}
 		name := entry.Name()
+		if _, cloning := c.clones.Load(name); cloning {
+			continue // not complete yet
+		}
 
 		repo, err := c.initCalendarRepo(name)
 		if err != nil {
-			fmt.Printf("failed to init/load '%s' repository: %v", name, err)
+			c.logger.Error("failed to init/load repository", "op", "load", "calendar", name, "error", err)
 			continue
 		}
+		if removed, err := removeUrlCredentials(repo); err != nil {
+			c.logger.Error("failed to remove credentials from remote urls", "op", "load", "calendar", name, "error", err)
+		} else if removed {
+			c.logger.Info("removed credentials from remote urls, use a CredentialProvider", "op", "load", "calendar", name)
+		}
 
-		var key []byte = nil
-		keyFile, err := c.fs.Open(fmt.Sprintf("%s.key", name))
-		if err == nil {
-			key, err = io.ReadAll(keyFile)
-			if err != nil {
-				fmt.Printf("failed to read encryption key for '%s' repository: %v", name, err)
-			}
-			keyFile.Close()
+		key, err := c.readKeyFile(name)
+		if err != nil {
+			c.logger.Error("failed to read encryption key", "op", "load", "calendar", name, "error", err)
 		}
 
-		c.calendars[name] = &Calendar{
+		meta, err := c.loadMetadata(name)
+		if err != nil {
+			c.logger.Error("failed to load metadata", "op", "load", "calendar", name, "error", err)
+			continue
+		}
+
+		cal := &Calendar{
 			Repository:    repo,
-			Tags:          nil, // TODO: load tags
 			EncryptionKey: key,
+			Metadata:      meta,
+			Locked:        key == nil && c.isLockedOnDisk(name),
+			head:          repoHead(repo),
 		}
+		c.loadCalendarConfig(name, cal)
+		c.calendars[name] = cal
 	}
 
 	// load tree + events
 	// TODO do not load files, but build tree from index.json
-	for _, cal := range c.calendars {
-		wt, _ := cal.Repository.Worktree()
-		eventsDir, _ := wt.Filesystem.Chroot(EventsDirName)
-		eventEntries, _ := eventsDir.ReadDir("/")
-		for _, eventEntry := range eventEntries {
-			if eventEntry.IsDir() {
-				continue
-			}
-
-			file, err := eventsDir.Open(eventEntry.Name())
-			if err != nil {
-				fmt.Printf("failed to open file '%s' from cal %s: %v\n", eventEntry.Name(), wt.Filesystem.Root(), err)
-				continue
-			}
-			defer file.Close()
-
-			var event Event
-			err = event.LoadFromFile(file, cal.EncryptionKey)
-			if err != nil {
-				fmt.Printf("failed to load event from file '%s' from cal %s: %v\n", eventEntry.Name(), wt.Filesystem.Root(), err)
-				continue
-			}
-
-			err = event.Validate()
-			if err != nil {
-				fmt.Printf("invalid event: %v\n", err)
-				continue
-			}
+	for name, cal := range c.calendars {
+		if cal.Locked {
+			continue // cannot be read without the password
+		}
 
+		events, quarantine := c.readCalendarEvents(name, cal.EncryptionKey)
+		cal.Quarantine = quarantine
+		for _, event := range events {
 			c.events[event.Id] = &event
 
 			err = c.intervalTree.InsertEvent(event)
 			if err != nil {
-				fmt.Printf("failed to insert event '%s' into index tree: %v\n", event.Id, err)
+				c.logger.Error("failed to insert event into index tree", "op", "load", "calendar", name, "event", event.Id, "error", err)
 				continue
 			}
 		}
@@ -138,79 +290,152 @@ func (c *Core) LoadCalendars() error {
 	return nil
 }
 
-// Clones a repository/calendar from url, using CORS proxy, if specified.
-func (c *Core) CloneCalendar(repoUrl *url.URL, password string) error {
+// Clones the repository without holding the Core lock (the network can be slow), only under the repository lease.
+// The Core lock is taken at the end to register the calendar. Must be called without holding c.mu.
+func (c *Core) cloneCalendar(ctx context.Context, repoUrl *url.URL, password string) error {
 	calendarName := calendarNameFromUrl(repoUrl)
-	if cal, ok := c.calendars[calendarName]; ok || cal != nil {
-		return errors.New("calendar with this name already exists")
+
+	c.mu.RLock()
+	_, exists := c.calendars[calendarName]
+	proxyUrl, provider, logger := c.proxyUrl, c.credentials, c.logger
+	c.mu.RUnlock()
+	if exists {
+		return fmt.Errorf("calendar '%s': %w", calendarName, ErrAlreadyExists)
+	}
+	if _, cloning := c.clones.LoadOrStore(calendarName, struct{}{}); cloning {
+		return fmt.Errorf("calendar '%s' is being cloned: %w", calendarName, ErrAlreadyExists)
 	}
+	defer c.clones.Delete(calendarName) // after the calendar is registered below
+
+	finalUrl, urlAuth := prepareRepoUrl(repoUrl, proxyUrl)
+	// the credentials in the url are used for the clone only, otherwise ask the provider
+	var auth transport.AuthMethod
+	if urlAuth != nil {
+		auth = urlAuth
+	} else {
+		var err error
+		if auth, err = askCredentials(provider, calendarName, gogit.DefaultRemoteName, repoUrl.String()); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := c.leaseRepo(calendarName, logger)
+	if err != nil {
+		return err
+	}
+	stopLease := c.keepLease(calendarName, logger) // the network can take longer than RepoLeaseTTL
+	newRepo, err := c.cloneRepo(ctx, calendarName, finalUrl, auth)
+	var cal *Calendar
+	if err == nil {
+		if cal, err = c.setupClone(calendarName, newRepo, repoUrl, password); err != nil {
+			// a clone without its metadata or key is useless, the next attempt starts from scratch
+			_ = gogitutil.RemoveAll(c.fs, calendarName)
+			_ = c.fs.Remove(keyFileName(calendarName))
+		}
+	}
+	stopLease()
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.loadCalendarConfig(calendarName, cal)
+	c.calendars[calendarName] = cal
+
+	c.notifyChange(calendarName)
+	return nil
+}
 
+// Clones the repository into the directory of the calendar. Removes the directory if the clone fails.
+// Must be called with the repository lease.
+func (c *Core) cloneRepo(ctx context.Context, name string, repoUrl *url.URL, auth transport.AuthMethod) (*gogit.Repository, error) {
 	// make sure that the repo dir is created
-	if err := c.fs.MkdirAll(calendarName, 0o755); err != nil {
-		return fmt.Errorf("create repo dir: %w", err)
+	if err := c.fs.MkdirAll(name, 0o755); err != nil {
+		return nil, fmt.Errorf("create repo dir: %w", err)
 	}
-	repoFS, err := c.fs.Chroot(calendarName)
+	repoFS, err := c.fs.Chroot(name)
 	if err != nil {
-		return fmt.Errorf("chroot repo dir: %w", err)
+		return nil, fmt.Errorf("chroot repo dir: %w", err)
 	}
 
 	// make sure that .git dir exists
 	if err := repoFS.MkdirAll(".git", 0o755); err != nil {
-		return fmt.Errorf("create .git: %w", err)
+		return nil, fmt.Errorf("create .git: %w", err)
 	}
 	dotGitFS, err := repoFS.Chroot(".git")
 	if err != nil {
-		return fmt.Errorf("chroot .git: %w", err)
+		return nil, fmt.Errorf("chroot .git: %w", err)
 	}
 
 	storage := gogitfs.NewStorage(dotGitFS, cache.NewObjectLRUDefault())
-	finalUrl, auth := prepareRepoUrl(repoUrl, c.proxyUrl)
-	// clone now
-	newRepo, err := gogit.Clone(storage, repoFS, &gogit.CloneOptions{
-		RemoteName: "origin",
-		URL:        finalUrl.String(),
+	repo, err := gogit.CloneContext(ctx, storage, repoFS, &gogit.CloneOptions{
+		RemoteName: gogit.DefaultRemoteName,
+		URL:        repoUrl.String(),
 		Auth:       auth,
+		Progress:   progressOf(ctx),
 	})
 	if err != nil {
-		c.RemoveCalendar(calendarName) // even on error, clone might create a directory, so let's delete it
-		return fmt.Errorf("git clone failed: %w", err)
+		_ = gogitutil.RemoveAll(c.fs, name) // even on error, clone might create a directory, so let's delete it
+		return nil, fmt.Errorf("git clone failed: %w", remoteError(err))
+	}
+	return repo, nil
+}
+
+// Prepares the freshly cloned repository: removes the proxy and credentials from the origin url,
+// loads the metadata and checks and stores the key derived from the password (if any).
+func (c *Core) setupClone(name string, repo *gogit.Repository, repoUrl *url.URL, password string) (*Calendar, error) {
+	// set the pure url without proxy and credentials, the git config is plaintext
+	if err := resetOriginUrl(repo, withoutCredentials(repoUrl.String())); err != nil {
+		return nil, err
+	}
+
+	meta, err := c.loadMetadata(name)
+	if err != nil {
+		return nil, err
 	}
 
 	var key []byte = nil
 	if len(password) != 0 {
-		key = encryption.DeriveKey(password, []byte(calendarName))
-
-		keyFile, err := c.fs.Create(fmt.Sprintf("%s.key", calendarName))
-		if err != nil {
-			return fmt.Errorf("failed to create key file: %w", err)
+		key = encryption.DeriveKey(password, meta.keySalt(name))
+		if !meta.checkKey(key) {
+			return nil, ErrWrongPassword
 		}
-		defer keyFile.Close()
-
-		if _, err = keyFile.Write(key); err != nil {
-			return fmt.Errorf("failed to write key to key file: %w", err)
+		if err := c.writeKeyFile(name, key); err != nil {
+			return nil, err
 		}
 	}
-	c.calendars[calendarName] = &Calendar{
-		Repository:    newRepo,
-		Tags:          nil, // TODO: load tags
+
+	return &Calendar{
+		Repository:    repo,
 		EncryptionKey: key,
-	}
+		Metadata:      meta,
+		head:          repoHead(repo),
+	}, nil
+}
 
-	// repair the remote url (set the pure url with auth, without proxy)
-	err = newRepo.DeleteRemote("origin")
+// Replaces the url of the origin remote (the clone stores the url it used).
+func resetOriginUrl(repo *gogit.Repository, remoteUrl string) error {
+	validUrl, err := validateRemoteUrl(remoteUrl)
 	if err != nil {
 		return err
 	}
-	err = c.AddRemote(calendarName, "origin", repoUrl.String())
-	if err != nil {
+	if err := repo.DeleteRemote(gogit.DefaultRemoteName); err != nil {
 		return err
 	}
-
-	return nil
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: gogit.DefaultRemoteName, URLs: []string{validUrl}})
+	return err
 }
 
-// Removes and deletes the whole calendar.
-func (c *Core) RemoveCalendar(name string) error {
+func (c *Core) removeCalendar(name string) error {
+	unlock, err := c.lockRepo(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// remove from map
 	delete(c.calendars, name)
 
@@ -219,44 +444,397 @@ func (c *Core) RemoveCalendar(name string) error {
 		return fmt.Errorf("failed to remove repo directory: %w", err)
 	}
 
-	// try to remove encryption key
-	_ = c.fs.Remove(fmt.Sprintf("%s.key", name))
+	// try to remove encryption key (or the lock marker)
+	_ = c.fs.Remove(keyFileName(name))
+	_ = c.fs.Remove(lockFileName(name))
+	c.notifyChange(name)
 
 	// TODO: This is the lazy way.
 	// LoadCalendars does full erase and load again for events map and tree. It also deletes all the repos, and reloads them from disk.
 	// Better approach would be to only delete the selected events.
 
-	return c.LoadCalendars()
+	return c.loadCalendars()
 }
 
-// Adds a new remote to the specified calendar repository.
-func (c *Core) AddRemote(calendar, remoteName, remoteUrl string) error {
-	var validUrl string
-	{
-		// validate URL (git doesn't do that when adding a remote, it fails afterwards with e.g. git fetch)
-		u := strings.TrimSuffix(remoteUrl, "/") // remove trailing "/"
-		if !strings.HasSuffix(u, ".git") {
-			return errors.New("remote url doesn't end with '.git'")
+func (c *Core) renameCalendar(oldName, newName string) error {
+	cal, ok := c.calendars[oldName]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", oldName, ErrNotFound)
+	}
+	if oldName == newName {
+		return nil // nothing to do
+	}
+	if err := c.checkNewCalendarName(newName); err != nil {
+		return err
+	}
+	if cal.Locked {
+		return fmt.Errorf("calendar '%s': %w", oldName, ErrLocked)
+	}
+
+	unlock, err := c.lockRepo(oldName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = c.transaction(func(tx *transaction) error {
+		if err := tx.renameCalendar(oldName, newName, fmt.Sprintf("Renamed calendar '%s' to '%s'", oldName, newName)); err != nil {
+			return err
 		}
-		parsedUrl, err := url.Parse(u)
-		if err != nil {
-			return fmt.Errorf("cannot parse remote url: %w", err)
+
+		// the key was derived with the old name
+		renamed := c.calendars[newName]
+		if renamed.IsEncrypted() && renamed.Metadata.KeySalt == "" {
+			meta := renamed.Metadata
+			meta.PlaintextFields = slices.Clone(meta.PlaintextFields)
+			meta.KeySalt = oldName
+			if err := tx.saveMetadata(newName, meta, ""); err != nil {
+				return err
+			}
+		}
+
+		for _, event := range c.eventsOfCalendar(oldName) {
+			moved := event.clone()
+			moved.Calendar = newName
+			tx.putEvent(&moved) // the times didn't change, no need to reindex
+			if err := tx.saveEvent(&moved, ""); err != nil {
+				return fmt.Errorf("failed to rewrite event '%s': %w", event.Id, err)
+			}
 		}
-		validUrl = parsedUrl.String()
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	cal, ok := c.calendars[calendar]
+	c.notifyChange(oldName)
+	return nil
+}
+
+func (c *Core) duplicateCalendar(source, newName string) error {
+	src, ok := c.calendars[source]
 	if !ok {
-		return fmt.Errorf("calendar not found: %s", calendar)
+		return fmt.Errorf("calendar '%s': %w", source, ErrNotFound)
+	}
+	if err := c.checkNewCalendarName(newName); err != nil {
+		return err
+	}
+	if src.Locked {
+		return fmt.Errorf("calendar '%s': %w", source, ErrLocked)
+	}
+
+	repo, err := c.initCalendarRepo(newName)
+	if err != nil {
+		return fmt.Errorf("failed to init calendar repo: %w", err)
 	}
 
-	_, err := cal.Repository.CreateRemote(&config.RemoteConfig{
-		Name: remoteName,
-		URLs: []string{validUrl},
+	key := slices.Clone(src.EncryptionKey)
+	meta := src.Metadata
+	meta.PlaintextFields = slices.Clone(meta.PlaintextFields)
+	if len(key) != 0 {
+		meta.KeySalt = string(src.Metadata.keySalt(source)) // the same password has to work
+		if err := c.writeKeyFile(newName, key); err != nil {
+			_ = gogitutil.RemoveAll(c.fs, newName)
+			return err
+		}
+	}
+
+	c.calendars[newName] = &Calendar{
+		Repository:    repo,
+		EncryptionKey: key,
+		head:          repoHead(repo),
+	}
+
+	err = c.transaction(func(tx *transaction) error {
+		if err := tx.saveMetadata(newName, meta, fmt.Sprintf("Duplicated calendar '%s'", source)); err != nil {
+			return err
+		}
+		if err := tx.saveConfig(newName, src.Config.clone(), ""); err != nil {
+			return err
+		}
+
+		events := c.eventsOfCalendar(source)
+		ids := make(map[uuid.UUID]uuid.UUID, len(events))
+		for _, event := range events {
+			ids[event.Id] = uuid.New()
+		}
+		for _, event := range events {
+			duplicate := event.clone()
+			duplicate.Id = ids[event.Id]
+			duplicate.Calendar = newName
+			if id, ok := ids[duplicate.ParentId]; ok {
+				duplicate.ParentId = id
+			}
+			if id, ok := ids[duplicate.DetachedFrom]; ok {
+				duplicate.DetachedFrom = id
+			}
+			if duplicate.Repeat != nil {
+				// the ids of children are derived from the parent id
+				for i, exception := range duplicate.Repeat.Exceptions {
+					if exception.Version() == 8 {
+						duplicate.Repeat.Exceptions[i] = generateCustomUUID(duplicate.Id, getTimeFromUUID(exception))
+					}
+				}
+			}
+			if err := tx.addEvent(&duplicate, ""); err != nil {
+				return fmt.Errorf("failed to copy event '%s': %w", event.Id, err)
+			}
+		}
+		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create a remote: %w", err)
+		delete(c.calendars, newName)
+		_ = gogitutil.RemoveAll(c.fs, newName)
+		_ = c.fs.Remove(keyFileName(newName))
+		return err
+	}
+	return nil
+}
+
+// Applies the change to the metadata of an encrypted calendar, rewrites all its events accordingly and commits.
+func (c *Core) updateMetadata(name, commitMsg string, change func(meta *Metadata)) error {
+	cal, ok := c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	if cal.Locked {
+		return fmt.Errorf("calendar '%s': %w", name, ErrLocked)
+	}
+	if !cal.IsEncrypted() {
+		return errors.New("calendar is not encrypted")
+	}
+
+	original := cal.Metadata
+	updated := original
+	updated.PlaintextFields = slices.Clone(original.PlaintextFields)
+	change(&updated)
+
+	if err := updated.Validate(); err != nil {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+	if reflect.DeepEqual(original, updated) {
+		return nil // nothing to do
+	}
+	if err := c.checkAllEventsLoaded(name); err != nil {
+		return err
 	}
 
+	return c.transaction(func(tx *transaction) error {
+		if err := tx.saveMetadata(name, updated, commitMsg); err != nil {
+			return err
+		}
+		if err := tx.saveConfig(name, cal.Config, ""); err != nil {
+			return fmt.Errorf("failed to re-encrypt config: %w", err)
+		}
+
+		// rewrite all events with the new metadata
+		for _, event := range c.events {
+			if event.Calendar != name {
+				continue
+			}
+			if err := tx.saveEvent(event, ""); err != nil {
+				return fmt.Errorf("failed to re-encrypt event '%s': %w", event.Id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Fails if an event file of the calendar isn't loaded (it's quarantined, or written by another instance since the load).
+// Only the loaded events are rewritten with new metadata, such a file would become unreadable.
+func (c *Core) checkAllEventsLoaded(name string) error {
+	entries, err := c.fs.ReadDir(c.fs.Join(name, EventsDirName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list event files: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), TempFilePrefix) {
+			continue
+		}
+		id, err := uuid.Parse(strings.TrimSuffix(entry.Name(), ".json"))
+		event, ok := c.events[id]
+		if err != nil || !ok || event.Calendar != name || entry.Name() != id.String()+".json" {
+			return fmt.Errorf("%w: event file '%s' of calendar '%s' isn't loaded, resolve or remove it first (see ListQuarantine)", ErrInvalidEvent, entry.Name(), name)
+		}
+	}
+	return nil
+}
+
+// Returns whether the name can be used for a new calendar (it's a valid directory name which isn't taken).
+func (c *Core) checkNewCalendarName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid calendar name '%s'", name)
+	}
+	if _, ok := c.calendars[name]; ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrAlreadyExists)
+	}
+	if _, err := c.fs.Stat(name); err == nil {
+		return fmt.Errorf("calendar '%s': %w", name, ErrAlreadyExists) // a directory which isn't loaded
+	}
+	return nil
+}
+
+// Returns the events of the calendar (the stored ones, not generated children), sorted by their start.
+func (c *Core) eventsOfCalendar(name string) []*Event {
+	events := []*Event{}
+	for _, event := range c.events {
+		if event.Calendar == name {
+			events = append(events, event)
+		}
+	}
+	slices.SortFunc(events, func(a, b *Event) int { return a.From.Compare(b.From) })
+	return events
+}
+
+// Reads and validates all events from the calendar worktree using the key.
+// Truncated files are restored from the last commit, leftover temp files are removed and unreadable files are quarantined,
+// as well as events whose Calendar field doesn't match the repo.
+// Returns the events and the quarantined files.
+func (c *Core) readCalendarEvents(name string, key []byte) ([]Event, []QuarantinedFile) {
+	cal := c.calendars[name]
+	wt, _ := cal.Repository.Worktree()
+	eventsDir, _ := wt.Filesystem.Chroot(EventsDirName)
+	eventEntries, _ := eventsDir.ReadDir("/")
+
+	events := make([]Event, 0, len(eventEntries))
+	quarantine := []QuarantinedFile{}
+	for _, eventEntry := range eventEntries {
+		if eventEntry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(eventEntry.Name(), TempFilePrefix) {
+			_ = eventsDir.Remove(eventEntry.Name()) // leftover of an interrupted write
+			continue
+		}
+
+		event, kind, err := readEventFile(eventsDir, eventEntry.Name(), key, cal.Metadata)
+		if kind == IssueCorruptJson && !isJsonFile(eventsDir, eventEntry.Name()) {
+			// the file was probably truncated by a crash, try the last committed version
+			gitPath := filepath.ToSlash(filepath.Join(EventsDirName, eventEntry.Name()))
+			if rErr := c.restoreCommittedFile(name, gitPath); rErr == nil {
+				c.logger.Info("restored truncated file from git", "op", "load", "calendar", name, "file", eventEntry.Name())
+				event, kind, err = readEventFile(eventsDir, eventEntry.Name(), key, cal.Metadata)
+			}
+		}
+		if err == nil && event.Calendar != name {
+			kind, err = IssueWrongCalendar, fmt.Errorf("calendar field is '%s' (see Core.Repair)", event.Calendar)
+		}
+		if err != nil {
+			c.logger.Warn("quarantined event file", "op", "load", "calendar", name, "file", eventEntry.Name(), "kind", kind, "error", err)
+			quarantine = append(quarantine, newQuarantinedFile(eventsDir, name, eventEntry.Name(), kind, err, len(key) != 0))
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	return events, quarantine
+}
+
+// Opens and reads a single event file.
+func loadEventFile(fs billy.Filesystem, filename string, key []byte, meta Metadata) (Event, error) {
+	var event Event
+
+	file, err := fs.Open(filename)
+	if err != nil {
+		return event, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	err = event.LoadFromFile(file, key, meta)
+	return event, err
+}
+
+// Returns whether the file contains a valid JSON (e.g. it's not empty or cut in half).
+func isJsonFile(fs billy.Filesystem, filename string) bool {
+	file, err := fs.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(file)
+	return err == nil && json.Valid(raw)
+}
+
+// Returns the name of the file storing the calendar encryption key.
+func keyFileName(name string) string {
+	return fmt.Sprintf("%s.key", name)
+}
+
+// Reads the stored encryption key of a calendar. Returns nil if there is none.
+func (c *Core) readKeyFile(name string) ([]byte, error) {
+	keyFile, err := c.fs.Open(keyFileName(name))
+	if err != nil {
+		return nil, nil // no key stored
+	}
+	defer keyFile.Close()
+
+	return io.ReadAll(keyFile)
+}
+
+// Stores the encryption key of a calendar (outside of the repository).
+func (c *Core) writeKeyFile(name string, key []byte) error {
+	err := c.writeFileAtomic(keyFileName(name), func(keyFile billy.File) error {
+		_, err := keyFile.Write(key)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write key to key file: %w", err)
+	}
+	return nil
+}
+
+// Replaces a file in the calendar worktree with its content from the last commit (HEAD).
+// Used to recover files left truncated or half-written. The index entry is reset to HEAD as well (it might contain
+// the broken version), so nothing is left to commit. A file which was never committed can't be restored.
+func (c *Core) restoreCommittedFile(name, relPath string) error {
+	cal := c.calendars[name]
+
+	head, err := cal.Repository.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	commit, err := cal.Repository.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	committed, err := commit.File(relPath)
+	if err != nil {
+		return fmt.Errorf("file '%s' isn't committed: %w", relPath, err)
+	}
+	contents, err := committed.Contents()
+	if err != nil {
+		return fmt.Errorf("failed to read committed file: %w", err)
+	}
+
+	err = c.writeFileAtomic(c.fs.Join(name, relPath), func(file billy.File) error {
+		_, err := file.Write([]byte(contents))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	idx, err := cal.Repository.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	entry, err := idx.Entry(relPath)
+	if errors.Is(err, index.ErrEntryNotFound) {
+		entry = idx.Add(relPath)
+	} else if err != nil {
+		return fmt.Errorf("failed to read index entry: %w", err)
+	}
+	if entry.Hash == committed.Hash && entry.Mode == committed.Mode {
+		return nil
+	}
+	entry.Hash, entry.Mode = committed.Hash, committed.Mode
+	if err := cal.Repository.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
 	return nil
 }
@@ -1,161 +1,138 @@
 package core
 
 import (
-	"errors"
 	"fmt"
 	"path/filepath"
 	"reflect"
 	"slices"
 	"time"
 
-	gogit "github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-billy/v5"
 	"github.com/google/uuid"
 )
 
 // Creates a new event and save it into git.
 func (c *Core) CreateEvent(event Event) (*Event, error) {
-	if _, ok := c.events[event.Id]; ok && event.Id != uuid.Nil {
-		return nil, fmt.Errorf("an event with this id already exists")
-	}
+	c.touch()
 
-	if err := event.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid event: %w", err)
-	}
-
-	c.events[event.Id] = &event
-
-	if err := c.intervalTree.InsertEvent(event); err != nil {
-		return nil, fmt.Errorf("failed to insert into index tree: %w", err)
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	err := c.saveAndCommitEvent(&event, fmt.Sprintf("Added event '%s'", event.Id))
+	var created *Event
+	err := c.transaction(func(tx *transaction) (err error) {
+		created, err = c.createEvent(tx, event)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to save event to repo: %w", err)
+		return nil, err
 	}
-
-	return &event, nil
+	return created, nil
 }
 
 // Updates a Basic event based on its id. Use UpdateRepeatingEvent method for repeating events.
 func (c *Core) UpdateEvent(event Event) (*Event, error) {
-	if err := event.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid event: %w", err)
-	}
+	c.touch()
 
-	originalEvent, exists := c.events[event.Id]
-	if !exists {
-		return nil, fmt.Errorf("no event found with id '%s'", event.Id)
-	}
-
-	oldEnd := originalEvent.getTreeEndTime()
-	newEnd := event.getTreeEndTime()
-
-	if originalEvent.From != event.From || oldEnd != newEnd { // update the interval tree
-		ids, found := c.intervalTree.tree.Find(originalEvent.From, oldEnd)
-		if found {
-			index := slices.Index(ids, originalEvent.Id)
-			if index != -1 {
-				updated := slices.Delete(ids, index, index+1)
-				if len(updated) == 0 {
-					_ = c.intervalTree.tree.Delete(originalEvent.From, oldEnd)
-				} else {
-					_ = c.intervalTree.tree.Insert(originalEvent.From, oldEnd, updated)
-				}
-			}
-		}
-		err := c.intervalTree.InsertEvent(event)
-		if err != nil {
-			return nil, fmt.Errorf("failed to reinsert event into tree: %w", err)
-		}
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	c.events[event.Id] = &event
-	if err := c.saveAndCommitEvent(&event, fmt.Sprintf("Updated event '%s'", event.Id)); err != nil {
+	var updated *Event
+	err := c.transaction(func(tx *transaction) (err error) {
+		updated, err = c.updateEvent(tx, event)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	return &event, nil
+	return updated, nil
 }
 
 // Removes a child event by adding an exception to its parent repeat rule.
 func (c *Core) UpdateRepeatingEvent(old, new Event, strat UpdateStrategy) (*Event, error) {
-	if err := old.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid old event: %w", err)
-	}
-	if err := new.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid new event: %w", err)
-	}
-	if !strat.IsValid() {
-		return nil, fmt.Errorf("incorrect strategy provided")
-	}
-	if old.Id != new.Id { // check if the event we are changing is the original Parent
-		return nil, fmt.Errorf("invalid update event: id '%s' does not match parent id '%s'", old.Id, new.Id)
-	}
+	c.touch()
 
-	switch strat {
-	case Current:
-		return c.updateCurrentChild(&new)
-	case Following:
-		return c.updateFollowingChildren(&old, &new)
-	case All:
-		return c.updateAllChildren(&old, &new)
-	default:
-		return nil, fmt.Errorf("update strategy %d isn't implemented", strat)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var updated *Event
+	err := c.transaction(func(tx *transaction) (err error) {
+		updated, err = c.updateRepeatingEvent(tx, old, new, strat)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return updated, nil
 }
 
 // Removes a real (basic/parent) event from the calendar. Use RemoveRepeatingEvent method for repeating events.
 func (c *Core) RemoveEvent(event Event) error {
-	if err := event.Validate(); err != nil {
-		return fmt.Errorf("invalid event: %w", err)
-	}
+	c.touch()
 
-	err := c.intervalTree.RemoveEvent(event)
-	if err != nil {
-		return fmt.Errorf("failed to delete event from interval tree: %w", err)
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// delete file from disk + git
-	err = c.deleteAndCommitEvent(event.Id, fmt.Sprintf("Delete event '%s'", event.Id))
-	if err != nil {
-		return fmt.Errorf("failed to delete event from git: %w", err)
-	}
-
-	delete(c.events, event.Id)
-	return nil
+	return c.transaction(func(tx *transaction) error {
+		return c.removeEvent(tx, event)
+	})
 }
 
 // Removes a child event by adding an exception to its parent repeat rule.
 func (c *Core) RemoveRepeatingEvent(event Event, strat UpdateStrategy) error {
-	if err := event.Validate(); err != nil {
-		return fmt.Errorf("invalid event: %w", err)
-	}
+	c.touch()
 
-	switch strat {
-	case Current:
-		return c.removeCurrentChild(&event)
-	// TODO:
-	// case Following:
-	// 	return c.removeFollowingChildren(&event)
-	// case All:
-	// 	return c.removeAllChildren(&event)
-	default:
-		return fmt.Errorf("update strategy %d isn't implemented", strat)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.transaction(func(tx *transaction) error {
+		return c.removeRepeatingEvent(tx, event, strat)
+	})
+}
+
+// Moves a basic event or a whole series (pass the parent) into another calendar, including the events detached from the series.
+// Only events with DetachedFrom set are known to be detached, the ones detached by older versions stay where they are.
+// The files are removed from the original repo and written (encrypted with the target key, if any) into the target one,
+// both repos get a commit. Fails with ErrInvalidEvent (and moves nothing) if the categories of the event or of a detached
+// event aren't tags of the target calendar. Returns the moved event.
+func (c *Core) MoveEvent(id uuid.UUID, targetCalendar string) (*Event, error) {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var moved *Event
+	err := c.transaction(func(tx *transaction) (err error) {
+		moved, err = c.moveEvent(tx, id, targetCalendar)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return moved, nil
 }
 
 // Returns event by id, or an error if it doesn't exist.
 func (c *Core) GetEvent(id uuid.UUID) (*Event, error) {
+	c.touch()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	e, ok := c.events[id]
 	if !ok {
-		return nil, fmt.Errorf("event with id: '%s' doesn't exist", id)
+		return nil, fmt.Errorf("event '%s': %w", id, ErrNotFound)
 	}
-	return e, nil
+	event := e.clone() // a deep copy, the stored one must not change outside of the lock
+	return &event, nil
 }
 
 // Returns an array of events which fall into the specified interval [from, to].
 func (c *Core) GetEvents(from, to time.Time) []Event {
+	c.touch()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	// query the interval tree
 	intervalsMatched, found := c.intervalTree.tree.AllIntersections(from, to)
 	if !found {
@@ -168,13 +145,13 @@ func (c *Core) GetEvents(from, to time.Time) []Event {
 		for _, eId := range intersection {
 			curEvent, ok := c.events[eId]
 			if !ok {
-				fmt.Printf("event with id: '%v' doesn't exist in events map WTF\n", eId)
+				c.logger.Error("event from index tree doesn't exist in events map", "op", "get_events", "event", eId)
 				continue
 			}
 
 			// if it doesn't repeat, just plain append to result
 			if curEvent.Repeat == nil {
-				result = append(result, *c.events[eId])
+				result = append(result, curEvent.clone())
 				continue
 			}
 
@@ -204,13 +181,13 @@ func (c *Core) GetEvents(from, to time.Time) []Event {
 					From:        firstStart,
 					To:          firstStart.Add(eventDuration),
 					Calendar:    curEvent.Calendar,
-					Tag:         curEvent.Tag,
+					Categories:  curEvent.Categories,
 					ParentId:    curEvent.Id,
 					Repeat:      curEvent.Repeat,
 				}
 				// ignore exceptions
 				if !slices.Contains(curEvent.Repeat.Exceptions, child.Id) {
-					result = append(result, child)
+					result = append(result, child.clone()) // not sharing the Repeat and Categories of the parent
 				}
 
 				firstStart = addUnit(firstStart, curEvent.Repeat.Interval, curEvent.Repeat.Frequency) // next occurrence
@@ -221,13 +198,167 @@ func (c *Core) GetEvents(from, to time.Time) []Event {
 	return result
 }
 
+// Same as GetEvents, but returns only the events with any (or all, if matchAll) of the categories.
+func (c *Core) GetEventsByCategories(from, to time.Time, categories []string, matchAll bool) []Event {
+	return slices.DeleteFunc(c.GetEvents(from, to), func(e Event) bool {
+		return !e.HasCategories(categories, matchAll)
+	})
+}
+
 // ------------------------------------------------ Helpers -------------------------------------------------
 
+// The implementations of the public methods above; they work inside a transaction.
+
+func (c *Core) createEvent(tx *transaction, event Event) (*Event, error) {
+	if _, ok := c.events[event.Id]; ok && event.Id != uuid.Nil {
+		return nil, fmt.Errorf("event '%s': %w", event.Id, ErrAlreadyExists)
+	}
+
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	if err := c.checkCategories(&event); err != nil {
+		return nil, err
+	}
+
+	if err := tx.addEvent(&event, fmt.Sprintf("Added event '%s'", event.Id)); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (c *Core) updateEvent(tx *transaction, event Event) (*Event, error) {
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	if err := c.checkCategories(&event); err != nil {
+		return nil, err
+	}
+
+	originalEvent, exists := c.events[event.Id]
+	if !exists {
+		return nil, fmt.Errorf("event '%s': %w", event.Id, ErrNotFound)
+	}
+	var detached []*Event
+	if event.IsParent() && originalEvent.Calendar != event.Calendar {
+		var err error
+		if detached, err = c.detachedToMove(event.Id, originalEvent.Calendar, event.Calendar); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.replaceEvent(originalEvent, &event, fmt.Sprintf("Updated event '%s'", event.Id)); err != nil {
+		return nil, err
+	}
+	if err := c.moveDetached(tx, detached, originalEvent.Calendar, event.Calendar); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (c *Core) updateRepeatingEvent(tx *transaction, old, new Event, strat UpdateStrategy) (*Event, error) {
+	if err := old.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: old: %w", ErrInvalidEvent, err)
+	}
+	if err := new.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: new: %w", ErrInvalidEvent, err)
+	}
+	if !strat.IsValid() {
+		return nil, fmt.Errorf("incorrect strategy provided")
+	}
+	if old.Id != new.Id { // check if the event we are changing is the original Parent
+		return nil, fmt.Errorf("%w: id '%s' does not match parent id '%s'", ErrInvalidEvent, old.Id, new.Id)
+	}
+
+	switch strat {
+	case Current:
+		return c.updateCurrentChild(tx, &new)
+	case Following:
+		return c.updateFollowingChildren(tx, &old, &new)
+	case All:
+		return c.updateAllChildren(tx, &old, &new)
+	default:
+		return nil, fmt.Errorf("update strategy %d isn't implemented", strat)
+	}
+}
+
+func (c *Core) moveEvent(tx *transaction, id uuid.UUID, targetCalendar string) (*Event, error) {
+	event, ok := c.events[id]
+	if !ok {
+		return nil, fmt.Errorf("event '%s': %w", id, ErrNotFound)
+	}
+	if event.IsChild() {
+		return nil, fmt.Errorf("%w: a child can't be moved alone, move its parent '%s'", ErrInvalidEvent, event.ParentId)
+	}
+	if _, ok := c.calendars[targetCalendar]; !ok {
+		return nil, fmt.Errorf("calendar '%s': %w", targetCalendar, ErrNotFound)
+	}
+
+	source := event.Calendar
+	if source == targetCalendar {
+		moved := *event
+		return &moved, nil // nothing to do
+	}
+
+	moved := event.clone()
+	moved.Calendar = targetCalendar
+	if err := c.checkCategories(&moved); err != nil {
+		return nil, err
+	}
+	var detached []*Event
+	if moved.IsParent() {
+		var err error
+		if detached, err = c.detachedToMove(id, source, targetCalendar); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.replaceEvent(event, &moved, fmt.Sprintf("Moved event '%s' from '%s' to '%s'", id, source, targetCalendar)); err != nil {
+		return nil, err
+	}
+	if err := c.moveDetached(tx, detached, source, targetCalendar); err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
+
+func (c *Core) removeEvent(tx *transaction, event Event) error {
+	if err := event.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+
+	stored, ok := c.events[event.Id]
+	if !ok {
+		return fmt.Errorf("event '%s': %w", event.Id, ErrNotFound)
+	}
+
+	// delete from memory, file from disk + git
+	return tx.removeEvent(stored, fmt.Sprintf("Delete event '%s'", event.Id))
+}
+
+func (c *Core) removeRepeatingEvent(tx *transaction, event Event, strat UpdateStrategy) error {
+	if err := event.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+
+	switch strat {
+	case Current:
+		return c.removeCurrentChild(tx, &event)
+	// TODO:
+	// case Following:
+	// 	return c.removeFollowingChildren(&event)
+	// case All:
+	// 	return c.removeAllChildren(&event)
+	default:
+		return fmt.Errorf("update strategy %d isn't implemented", strat)
+	}
+}
+
 // Updates single generated/child event by adding it to its Parent repeat exceptions and creating a brand new event instead.
-func (c *Core) updateCurrentChild(updated *Event) (*Event, error) {
+func (c *Core) updateCurrentChild(tx *transaction, updated *Event) (*Event, error) {
 	parent, ok := c.events[updated.ParentId]
 	if !ok || parent == nil || !parent.IsParent() {
-		return nil, fmt.Errorf("no valid parent found")
+		return nil, fmt.Errorf("parent: %w", ErrNotFound)
 	}
 
 	if parent.Repeat == nil {
@@ -235,8 +366,9 @@ func (c *Core) updateCurrentChild(updated *Event) (*Event, error) {
 	}
 
 	// update parent event with the new exception
-	parent.Repeat.Exceptions = append(parent.Repeat.Exceptions, updated.Id)
-	if err := c.saveAndCommitEvent(parent, fmt.Sprintf("Added exception to parent '%s'", parent.Id)); err != nil {
+	updatedParent := parent.clone()
+	updatedParent.Repeat.Exceptions = append(updatedParent.Repeat.Exceptions, updated.Id)
+	if err := tx.replaceEvent(parent, &updatedParent, fmt.Sprintf("Added exception to parent '%s'", parent.Id)); err != nil {
 		return nil, fmt.Errorf("failed to save parent event: %w", err)
 	}
 
@@ -244,42 +376,41 @@ func (c *Core) updateCurrentChild(updated *Event) (*Event, error) {
 	detachedEvent := *updated         // shallow copy
 	detachedEvent.Repeat = nil        // not repeating anymore
 	detachedEvent.ParentId = uuid.Nil // not child anymore
-	detachedEvent.Id = uuid.Nil       // set to nil; CreateEvent will asign a new one
+	detachedEvent.Id = uuid.Nil       // set to nil; createEvent will asign a new one
+	detachedEvent.DetachedFrom = parent.Id
 
-	return c.CreateEvent(detachedEvent) // save as new
+	return c.createEvent(tx, detachedEvent) // save as new
 }
 
 // Splits the time series into two by stopping the original parent event from repeating further and creating brand new parent with updated properties.
-func (c *Core) updateFollowingChildren(old, new *Event) (*Event, error) {
+func (c *Core) updateFollowingChildren(tx *transaction, old, new *Event) (*Event, error) {
 	parent, ok := c.events[new.ParentId]
 	if !ok || parent == nil || !parent.IsParent() {
-		return nil, fmt.Errorf("no valid parent found")
+		return nil, fmt.Errorf("parent: %w", ErrNotFound)
 	}
 
-	// save originals for rollback
-	originalUntil := parent.Repeat.Until
 	originalCount := parent.Repeat.Count
-	originalExceptions := append([]uuid.UUID{}, parent.Repeat.Exceptions...) // deep copy
 
-	parent.Repeat.Until = addUnit(old.From, -1, old.Repeat.Frequency) // cap parent at start of change
-	parent.Repeat.Count = 0                                           // enforce Until logic over Count
+	cappedParent := parent.clone()
+	cappedParent.Repeat.Until = addUnit(old.From, -1, old.Repeat.Frequency) // cap parent at start of change
+	cappedParent.Repeat.Count = 0                                           // enforce Until logic over Count
 
 	// split exceptions
-	exBefore, exAfter := splitExceptions(parent.Repeat.Exceptions, new.From)
-	parent.Repeat.Exceptions = exBefore
+	exBefore, exAfter := splitExceptions(cappedParent.Repeat.Exceptions, new.From)
+	cappedParent.Repeat.Exceptions = exBefore
 
-	if err := c.saveAndCommitEvent(parent, fmt.Sprintf("Capped parent event '%s'", parent.Id)); err != nil {
+	if err := tx.replaceEvent(parent, &cappedParent, fmt.Sprintf("Capped parent event '%s'", parent.Id)); err != nil {
 		return nil, fmt.Errorf("failed to commit parent event: %w", err)
 	}
 
 	// create the new parent for the second half of the time series
-	newEvent := *new             // shallow copy
+	newEvent := new.clone()      // deep copy
 	newEvent.Id = uuid.New()     // assign new id
 	newEvent.ParentId = uuid.Nil // not child anymore
 
 	if originalCount != 0 && newEvent.Repeat != nil {
 		// shorten the repeat for the second half
-		_, elapsed := firstOccurrenceAtOrAfter(old.From, parent)
+		_, elapsed := firstOccurrenceAtOrAfter(old.From, &cappedParent)
 		if elapsed <= 0 {
 			// the split is at the first occurance -> nothing to subtract (basically update all)
 			newEvent.Repeat.Count = originalCount
@@ -302,16 +433,9 @@ func (c *Core) updateFollowingChildren(old, new *Event) (*Event, error) {
 		}
 	}
 
-	createdEvent, err := c.CreateEvent(newEvent)
+	createdEvent, err := c.createEvent(tx, newEvent)
 	if err != nil {
-		// rollback the parent cap
-		parent.Repeat.Until = originalUntil
-		parent.Repeat.Count = originalCount
-		parent.Repeat.Exceptions = originalExceptions
-		if rbErr := c.saveAndCommitEvent(parent, fmt.Sprintf("Rolled back cap on parent event '%s'", parent.Id)); rbErr != nil {
-			return nil, fmt.Errorf("failed to create new event: %w; rollback also failed: %v", err, rbErr)
-		}
-		return nil, fmt.Errorf("failed to create new event: %w", err)
+		return nil, fmt.Errorf("failed to create new event: %w", err) // the transaction rolls back the parent cap
 	}
 
 	return createdEvent, nil
@@ -319,201 +443,209 @@ func (c *Core) updateFollowingChildren(old, new *Event) (*Event, error) {
 
 // Updates the entire repeating series by only modifying the parent. That means all generated child events get updated as well.
 // Both old and new arguments are child events.
-func (c *Core) updateAllChildren(old, new *Event) (*Event, error) {
+func (c *Core) updateAllChildren(tx *transaction, old, new *Event) (*Event, error) {
 	if old.IsParent() || new.IsParent() {
-		return nil, fmt.Errorf("updateRepeatingAll works with child events")
+		return nil, fmt.Errorf("%w: updateRepeatingAll works with child events", ErrInvalidEvent)
 	}
 
 	parent, ok := c.events[old.ParentId]
 	if !ok || parent == nil || !parent.IsParent() {
-		return nil, fmt.Errorf("no valid parent found")
+		return nil, fmt.Errorf("parent: %w", ErrNotFound)
 	}
 
 	fromDiff := new.From.Sub(old.From)
 	toDiff := new.To.Sub(old.To)
 
 	fromChanged := fromDiff != 0
-	toChanged := toDiff != 0
 	repeatChanged := !reflect.DeepEqual(old.Repeat, new.Repeat)
 
-	needsReindex := fromChanged || toChanged || repeatChanged
-
-	if needsReindex {
-		if err := c.intervalTree.RemoveEvent(*parent); err != nil {
-			return nil, fmt.Errorf("failed to remove parent from interval tree: %w", err)
-		}
-	}
+	updatedParent := parent.clone()
 
 	// shift all exceptions by the time fromDiff
-	if fromChanged && parent.Repeat != nil {
-		for i := range parent.Repeat.Exceptions {
-			parent.Repeat.Exceptions[i] = getShiftedUUID(parent.Repeat.Exceptions[i], fromDiff)
+	if fromChanged && updatedParent.Repeat != nil {
+		for i := range updatedParent.Repeat.Exceptions {
+			updatedParent.Repeat.Exceptions[i] = getShiftedUUID(updatedParent.Repeat.Exceptions[i], fromDiff)
 		}
 	}
 
 	if repeatChanged {
-		parent.Repeat = new.Repeat
+		updatedParent.Repeat = new.clone().Repeat
 	}
 
-	parent.Title = new.Title
-	parent.Location = new.Location
-	parent.Description = new.Description
-	parent.From = parent.From.Add(fromDiff)
-	parent.To = parent.To.Add(toDiff)
-	parent.Tag = new.Tag
-	parent.Calendar = new.Calendar
-
-	if needsReindex {
-		if err := c.intervalTree.InsertEvent(*parent); err != nil {
-			return nil, fmt.Errorf("failed to reinsert parent: %w", err)
+	updatedParent.Title = new.Title
+	updatedParent.Location = new.Location
+	updatedParent.Description = new.Description
+	updatedParent.From = parent.From.Add(fromDiff)
+	updatedParent.To = parent.To.Add(toDiff)
+	updatedParent.Categories = slices.Clone(new.Categories)
+	updatedParent.Calendar = new.Calendar
+	if err := c.checkCategories(&updatedParent); err != nil {
+		return nil, err
+	}
+	var detached []*Event
+	if parent.Calendar != updatedParent.Calendar {
+		var err error
+		if detached, err = c.detachedToMove(parent.Id, parent.Calendar, updatedParent.Calendar); err != nil {
+			return nil, err
 		}
 	}
 
-	if err := c.saveAndCommitEvent(parent,
+	// reindexes the parent if its interval changed (and moves it, if the calendar changed)
+	if err := tx.replaceEvent(parent, &updatedParent,
 		fmt.Sprintf("Updated time series (parent '%s')", parent.Id),
 	); err != nil {
 		return nil, fmt.Errorf("failed to save parent: %w", err)
 	}
+	if err := c.moveDetached(tx, detached, parent.Calendar, updatedParent.Calendar); err != nil {
+		return nil, err
+	}
 
-	return parent, nil
+	return &updatedParent, nil
 }
 
-func (c *Core) removeCurrentChild(event *Event) error {
+func (c *Core) removeCurrentChild(tx *transaction, event *Event) error {
 	parent, ok := c.events[event.ParentId]
 	if !ok || parent == nil || !parent.IsParent() {
-		return fmt.Errorf("no valid parent found")
+		return fmt.Errorf("parent: %w", ErrNotFound)
 	}
 
+	updatedParent := parent.clone()
+
 	// if exception doesn't exist yet
-	if !slices.Contains(parent.Repeat.Exceptions, event.Id) {
+	if !slices.Contains(updatedParent.Repeat.Exceptions, event.Id) {
 		// add date to parent exceptions
-		newException := event.Id
-		parent.Repeat.Exceptions = append(parent.Repeat.Exceptions, newException)
-
-		// update/overwrite the file in repo
-		err := c.saveAndCommitEvent(parent, fmt.Sprintf("Updated event '%s'", event.Id))
-		if err != nil {
-			return fmt.Errorf("failed to save event to repo: %w", err)
-		}
+		updatedParent.Repeat.Exceptions = append(updatedParent.Repeat.Exceptions, event.Id)
 	}
 
 	// TODO: finish this
 	// cleanup the Parent if all Children are in Exceptions
 	// either (Count != 0 and Count = len(Exceptions)) or TODO: hard to know from the Until
-	if (parent.Repeat.Count != 0 && len(parent.Repeat.Exceptions) == parent.Repeat.Count) || (!parent.Repeat.Until.IsZero() && false) { // ughhh
-		err := c.deleteAndCommitEvent(parent.Id, fmt.Sprintf("Delete event '%s'", parent.Id))
-		if err != nil {
-			return fmt.Errorf("failed to delete event from git: %w", err)
+	if (updatedParent.Repeat.Count != 0 && len(updatedParent.Repeat.Exceptions) == updatedParent.Repeat.Count) || (!updatedParent.Repeat.Until.IsZero() && false) { // ughhh
+		if err := tx.removeEvent(parent, fmt.Sprintf("Delete event '%s'", parent.Id)); err != nil {
+			return err
 		}
-		delete(c.events, parent.Id)
+		return nil
+	}
+
+	if len(updatedParent.Repeat.Exceptions) == len(parent.Repeat.Exceptions) {
+		return nil // nothing changed
 	}
 
+	// update/overwrite the file in repo
+	if err := tx.replaceEvent(parent, &updatedParent, fmt.Sprintf("Updated event '%s'", event.Id)); err != nil {
+		return fmt.Errorf("failed to save event to repo: %w", err)
+	}
 	return nil
 }
 
-// Serializes event to JSON, saves to file, stages and commits with given message.
-func (c *Core) saveAndCommitEvent(event *Event, commitMsg string) error {
+// Returns the events detached from the series (see Event.DetachedFrom) which are still in the source calendar, sorted by time.
+// Fails if their categories aren't tags of the target calendar (see checkCategories), so call it before anything is written.
+// Events detached before DetachedFrom existed can't be found: the exceptions of the parent hold the ids of the removed
+// occurrences, not of the detached events (which got new ids and may have been moved to another time).
+func (c *Core) detachedToMove(parentId uuid.UUID, source, targetCalendar string) ([]*Event, error) {
+	detached := []*Event{}
+	for _, event := range c.events {
+		if event.DetachedFrom != parentId || event.Calendar != source {
+			continue
+		}
+		moved := *event
+		moved.Calendar = targetCalendar
+		if err := c.checkCategories(&moved); err != nil {
+			return nil, fmt.Errorf("detached event '%s': %w", event.Id, err)
+		}
+		detached = append(detached, event)
+	}
+	slices.SortFunc(detached, func(a, b *Event) int { return a.From.Compare(b.From) })
+	return detached, nil
+}
+
+// Moves the detached events (see detachedToMove) of a moved series from the source into the target calendar.
+func (c *Core) moveDetached(tx *transaction, detached []*Event, source, targetCalendar string) error {
+	for _, event := range detached {
+		moved := event.clone()
+		moved.Calendar = targetCalendar
+		msg := fmt.Sprintf("Moved event '%s' from '%s' to '%s'", event.Id, source, targetCalendar)
+		if err := tx.replaceEvent(event, &moved, msg); err != nil {
+			return fmt.Errorf("failed to move detached event '%s': %w", event.Id, err)
+		}
+	}
+	return nil
+}
+
+// Serializes event to JSON, saves to file and stages it. Returns the calendar the event belongs to.
+func (c *Core) stageEvent(event *Event) (*Calendar, error) {
 	// -------- write to disk --------
 	cal, ok := c.calendars[event.Calendar]
 	if !ok {
-		return fmt.Errorf("the specified event.Calendar doesn't exist")
+		return nil, fmt.Errorf("calendar '%s': %w", event.Calendar, ErrNotFound)
 	}
 	if cal.Repository == nil {
-		return fmt.Errorf("calendar repo not initialized")
+		return nil, fmt.Errorf("calendar repo not initialized")
+	}
+	if cal.Locked {
+		return nil, fmt.Errorf("calendar '%s': %w", event.Calendar, ErrLocked)
 	}
 
 	// ensure events directory exists
 	dirPath := c.fs.Join(event.Calendar, EventsDirName)
 	if err := c.fs.MkdirAll(dirPath, 0o755); err != nil {
-		return fmt.Errorf("failed mkdir events: %w", err)
+		return nil, fmt.Errorf("failed mkdir events: %w", err)
 	}
 
 	filename := fmt.Sprintf("%s.json", event.Id)
 	filePath := c.fs.Join(dirPath, filename)
 
-	// create truncates/overwrites the file if it exists
-	file, err := c.fs.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-
-	err = event.WriteToFile(file, cal.EncryptionKey)
+	// replaces the file (if it exists) only after the new content is fully written
+	err := c.writeFileAtomic(filePath, func(file billy.File) error {
+		return event.WriteToFile(file, cal.EncryptionKey, cal.Metadata)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to write event to file: %w", err)
+		return nil, fmt.Errorf("failed to write event to file: %w", err)
 	}
-	defer file.Close()
 
 	// -------- add to git repo --------
 	w, err := cal.Repository.Worktree()
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// stage
 	gitPath := filepath.ToSlash(c.fs.Join(EventsDirName, filename))
 	if _, err := w.Add(gitPath); err != nil {
-		return fmt.Errorf("git add: %w", err)
+		return nil, fmt.Errorf("git add: %w", err)
 	}
 
-	// commit
-	_, err = w.Commit(commitMsg, &gogit.CommitOptions{
-		Author: &object.Signature{
-			Name:  GitAuthorName,
-			Email: "",
-			When:  time.Now(),
-		},
-	})
-
-	if err != nil && !errors.Is(err, gogit.ErrEmptyCommit) {
-		return fmt.Errorf("failed to git commit: %w", err)
-	}
-	return nil
+	return cal, nil
 }
 
-// Removes event from filesystem and commits the change.
-func (c *Core) deleteAndCommitEvent(eventId uuid.UUID, commitMsg string) error {
-	event, ok := c.events[eventId]
-	if !ok {
-		return fmt.Errorf("failed to find event by id")
-	}
-	filename := fmt.Sprintf("%s.json", eventId)
+// Removes event file from disk and from the git index. Returns the calendar the event belonged to.
+func (c *Core) unstageEvent(event *Event) (*Calendar, error) {
+	filename := fmt.Sprintf("%s.json", event.Id)
 
 	// -------- remove from disk --------
 	filePath := c.fs.Join(event.Calendar, EventsDirName, filename)
 	if err := c.fs.Remove(filePath); err != nil {
 		// TODO maybe continue, to clean the git from this file
-		return fmt.Errorf("failed to remove file from disk: %w", err)
+		return nil, fmt.Errorf("failed to remove file from disk: %w", err)
 	}
 
 	// -------- remove from git --------
 	cal, ok := c.calendars[event.Calendar]
 	if !ok {
-		return fmt.Errorf("calendar doesn't exist")
+		return nil, fmt.Errorf("calendar '%s': %w", event.Calendar, ErrNotFound)
 	}
 	if cal.Repository == nil {
-		return fmt.Errorf("calendar repo not initialized")
+		return nil, fmt.Errorf("calendar repo not initialized")
 	}
 
 	w, err := cal.Repository.Worktree()
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
 	}
 
 	gitPath := filepath.ToSlash(c.fs.Join(EventsDirName, filename))
 	if _, err := w.Remove(gitPath); err != nil {
-		return fmt.Errorf("git remove: %w", err)
-	}
-
-	_, err = w.Commit(commitMsg, &gogit.CommitOptions{
-		Author: &object.Signature{
-			Name:  GitAuthorName,
-			Email: "",
-			When:  time.Now(),
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to git commit: %w", err)
+		return nil, fmt.Errorf("git remove: %w", err)
 	}
 
-	return nil
+	return cal, nil
 }
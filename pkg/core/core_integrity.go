@@ -0,0 +1,275 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/google/uuid"
+)
+
+// Kinds of problems found by Core.CheckIntegrity.
+type IssueKind string
+
+const (
+	IssueUnreadable     IssueKind = "unreadable"      // the file can't be read at all
+	IssueCorruptJson    IssueKind = "corrupt_json"    // not a valid JSON or not an event
+	IssueBadFileName    IssueKind = "bad_file_name"   // the file name is not <uuid>.json
+	IssueDecryptFailed  IssueKind = "decrypt_failed"  // encrypted with a different key (or damaged)
+	IssueInvalidEvent   IssueKind = "invalid_event"   // the event doesn't pass Event.Validate
+	IssueWrongCalendar  IssueKind = "wrong_calendar"  // the Calendar field doesn't match the repo
+	IssueDanglingParent IssueKind = "dangling_parent" // the ParentId points to a nonexistent event
+	IssueDuplicateId    IssueKind = "duplicate_id"    // the same id exists in another calendar
+	IssueUntracked      IssueKind = "untracked"       // the file is not tracked by git
+	IssueModified       IssueKind = "modified"        // the file differs from the last commit (changed, deleted or staged only)
+)
+
+// A single problem found in a calendar repository.
+type IntegrityIssue struct {
+	Calendar string    `json:"calendar"`
+	Path     string    `json:"path"` // relative to the calendar repo root
+	EventId  uuid.UUID `json:"event_id,omitzero"`
+	Kind     IssueKind `json:"kind"`
+	Message  string    `json:"message,omitzero"`
+	Repaired bool      `json:"repaired,omitzero"`
+}
+
+// The result of Core.CheckIntegrity or Core.Repair.
+type IntegrityReport struct {
+	Issues  []IntegrityIssue `json:"issues"`
+	Skipped []string         `json:"skipped,omitzero"` // locked calendars, which can't be checked
+}
+
+// Returns whether no issues were found (or all of them were repaired).
+func (r *IntegrityReport) IsClean() bool {
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks files of all calendars on disk and returns a report of everything that's wrong with them. Nothing is changed.
+func (c *Core) CheckIntegrity() (*IntegrityReport, error) {
+	c.touch()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.checkIntegrity(false)
+}
+
+// Same as CheckIntegrity, but also fixes what is safely fixable and commits the fixes:
+//   - truncated/corrupt files are restored from the last commit,
+//   - the Calendar field is set to the repo the event is in,
+//   - valid untracked or modified event files are committed, deleted ones are committed as removed,
+//   - leftover temp files are removed.
+//
+// Decrypt failures, invalid events, dangling parents (orphans), duplicate ids and changes outside the events
+// directory are only reported. The calendars are reloaded afterwards.
+func (c *Core) Repair() (*IntegrityReport, error) {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report, err := c.checkIntegrity(true)
+	if err != nil {
+		return report, err
+	}
+	if err := c.loadCalendars(); err != nil {
+		return report, fmt.Errorf("failed to reload calendars: %w", err)
+	}
+	return report, nil
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+func (c *Core) checkIntegrity(repair bool) (*IntegrityReport, error) {
+	report := &IntegrityReport{Issues: []IntegrityIssue{}}
+	seen := make(map[uuid.UUID]string) // event id -> calendar, to find duplicates
+
+	for _, name := range slices.Sorted(maps.Keys(c.calendars)) {
+		if c.calendars[name].Locked {
+			report.Skipped = append(report.Skipped, name)
+			continue
+		}
+		if err := c.checkCalendar(name, repair, report, seen); err != nil {
+			return report, fmt.Errorf("failed to check calendar '%s': %w", name, err)
+		}
+	}
+	return report, nil
+}
+
+// Checks a single calendar and appends found issues to the report. Repairs them, if repair is set.
+func (c *Core) checkCalendar(name string, repair bool, report *IntegrityReport, seen map[uuid.UUID]string) error {
+	if repair {
+		unlock, err := c.lockRepo(name)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	cal := c.calendars[name]
+	w, err := cal.Repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	eventsDir, err := w.Filesystem.Chroot(EventsDirName)
+	if err != nil {
+		return fmt.Errorf("failed to open events dir: %w", err)
+	}
+
+	// taken before any repair, so that the repairs themselves don't show up as modifications
+	status, err := w.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	issues := []IntegrityIssue{}
+	add := func(issue IntegrityIssue) int {
+		issue.Calendar = name
+		issues = append(issues, issue)
+		return len(issues) - 1
+	}
+
+	// -------- read and validate every event --------
+	entries, _ := eventsDir.ReadDir("/")
+	events := make(map[uuid.UUID]*Event, len(entries))
+	valid := make(map[string]bool) // paths of event files which can be committed as they are
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), TempFilePrefix) {
+			continue // temp files are reported as untracked below
+		}
+		gitPath := path.Join(EventsDirName, entry.Name())
+
+		event, kind, err := readEventFile(eventsDir, entry.Name(), cal.EncryptionKey, cal.Metadata)
+		if err != nil {
+			issue := add(IntegrityIssue{Path: gitPath, Kind: kind, Message: err.Error()})
+			if repair && (kind == IssueCorruptJson || kind == IssueUnreadable) {
+				if err := c.restoreCommittedFile(name, gitPath); err == nil {
+					issues[issue].Repaired = true
+					valid[gitPath] = true
+				}
+			}
+			continue
+		}
+
+		if event.Calendar != name {
+			issue := add(IntegrityIssue{
+				Path: gitPath, EventId: event.Id, Kind: IssueWrongCalendar,
+				Message: fmt.Sprintf("calendar field is '%s'", event.Calendar),
+			})
+			if repair {
+				event.Calendar = name
+				if _, err := c.stageEvent(&event); err == nil {
+					issues[issue].Repaired = true
+				}
+			}
+		}
+
+		if other, ok := seen[event.Id]; ok {
+			add(IntegrityIssue{
+				Path: gitPath, EventId: event.Id, Kind: IssueDuplicateId,
+				Message: fmt.Sprintf("the same id exists in calendar '%s'", other),
+			})
+		} else {
+			seen[event.Id] = name
+		}
+
+		valid[gitPath] = true
+		events[event.Id] = &event
+	}
+
+	for _, event := range events {
+		if event.ParentId == uuid.Nil {
+			continue
+		}
+		if _, ok := events[event.ParentId]; ok {
+			continue
+		}
+		add(IntegrityIssue{ // an orphan, only the user knows whether to remove it or to detach it
+			Path: eventGitPath(event.Id), EventId: event.Id, Kind: IssueDanglingParent,
+			Message: fmt.Sprintf("parent '%s' doesn't exist", event.ParentId),
+		})
+	}
+
+	// -------- compare the worktree with git --------
+	for _, p := range slices.Sorted(maps.Keys(status)) {
+		s := status[p]
+		if s.Staging == gogit.Unmodified && s.Worktree == gogit.Unmodified {
+			continue
+		}
+
+		kind := IssueModified
+		if s.Worktree == gogit.Untracked {
+			kind = IssueUntracked
+		}
+		issue := add(IntegrityIssue{Path: p, Kind: kind, Message: fmt.Sprintf("git status '%c%c'", s.Staging, s.Worktree)})
+		if !repair {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(path.Base(p), TempFilePrefix):
+			if err := w.Filesystem.Remove(p); err == nil {
+				issues[issue].Repaired = true
+			}
+		case path.Dir(p) != EventsDirName:
+			// only event files are committed, anything else is just reported
+		case s.Worktree == gogit.Deleted:
+			if _, err := w.Remove(p); err == nil {
+				issues[issue].Repaired = true
+			}
+		case valid[p]:
+			if _, err := w.Add(p); err == nil {
+				issues[issue].Repaired = true
+			}
+		}
+	}
+
+	report.Issues = append(report.Issues, issues...)
+
+	if repair && slices.ContainsFunc(issues, func(i IntegrityIssue) bool { return i.Repaired }) {
+		if err := c.commit(cal, "Repaired calendar"); err != nil {
+			return err
+		}
+		c.notifyChange(name)
+	}
+	return nil
+}
+
+// Reads, parses and validates a single event file. If it fails, the kind of the problem is returned as well.
+func readEventFile(fs billy.Filesystem, filename string, key []byte, meta Metadata) (Event, IssueKind, error) {
+	raw, err := readWholeFile(fs, filename)
+	if err != nil {
+		return Event{}, IssueUnreadable, err
+	}
+	if !json.Valid(raw) {
+		return Event{}, IssueCorruptJson, errors.New("file is not a valid JSON")
+	}
+	if _, err := uuid.Parse(strings.TrimSuffix(filename, ".json")); err != nil || !strings.HasSuffix(filename, ".json") {
+		return Event{}, IssueBadFileName, fmt.Errorf("file name is not UUID.json but '%s'", filename)
+	}
+
+	event, err := loadEventFile(fs, filename, key, meta)
+	if err != nil {
+		if len(key) != 0 {
+			return event, IssueDecryptFailed, err
+		}
+		return event, IssueCorruptJson, err
+	}
+	if err := event.Validate(); err != nil {
+		return event, IssueInvalidEvent, err
+	}
+	return event, "", nil
+}
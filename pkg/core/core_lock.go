@@ -0,0 +1,235 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/git-calendar/core/pkg/encryption"
+)
+
+// Locks an encrypted calendar. The key is wiped from memory and storage, and the calendar events are unloaded.
+// Use UnlockCalendar with the password to load them again.
+func (c *Core) LockCalendar(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lockCalendar(name)
+}
+
+// Unlocks a locked calendar with its password and loads its events.
+// The password is verified by the key check in the calendar metadata. Calendars created before it are verified
+// by decrypting the events (and get the key check), so for such an empty calendar any password is accepted.
+func (c *Core) UnlockCalendar(name, password string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cal, ok := c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	if !cal.Locked {
+		return nil // nothing to do
+	}
+
+	key := encryption.DeriveKey(password, cal.Metadata.keySalt(name))
+	if !cal.Metadata.checkKey(key) {
+		return ErrWrongPassword
+	}
+
+	events, quarantine := c.readCalendarEvents(name, key)
+	if len(events) == 0 && slices.ContainsFunc(quarantine, func(q QuarantinedFile) bool { return q.Kind == IssueDecryptFailed }) {
+		return ErrWrongPassword // not a single event could be decrypted
+	}
+
+	if err := c.writeKeyFile(name, key); err != nil {
+		return err
+	}
+	if err := c.fs.Remove(lockFileName(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+
+	cal.EncryptionKey = key
+	cal.Locked = false
+	cal.Quarantine = quarantine
+	c.loadCalendarConfig(name, cal)
+
+	for _, event := range events {
+		c.events[event.Id] = &event
+		if err := c.intervalTree.InsertEvent(event); err != nil {
+			c.logger.Error("failed to insert event into index tree", "op", "unlock", "calendar", name, "event", event.Id, "error", err)
+		}
+	}
+
+	if cal.Metadata.KeyCheck == "" && len(events) != 0 { // verified by the events, remember it
+		if err := c.addKeyCheck(name); err != nil {
+			c.logger.Error("failed to add key check", "op", "unlock", "calendar", name, "error", err)
+		}
+	}
+	c.restartIdle() // the unlock itself doesn't count as idle time
+	return nil
+}
+
+// Returns whether the calendar is locked.
+func (c *Core) IsCalendarLocked(name string) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cal, ok := c.calendars[name]
+	if !ok {
+		return false, fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	return cal.Locked, nil
+}
+
+// Sets the idle timeout after which all encrypted calendars get locked (and their keys wiped from memory). Zero disables the auto-lock.
+//
+// A timer locks them when the timeout passes without any operation on the calendar data (events, tags, config,
+// encryption settings, quarantine, integrity). The idle time is also checked at the beginning of every such operation,
+// in case the timer fired late (e.g. while the app was suspended). Syncing and listing calendars or remotes don't count
+// as activity, they may run in the background.
+func (c *Core) SetAutoLock(timeout time.Duration) {
+	c.lastActivity.Store(time.Now().UnixNano())
+	c.autoLockTimeout.Store(int64(timeout))
+
+	c.autoLockMu.Lock()
+	defer c.autoLockMu.Unlock()
+	if c.autoLockTimer != nil {
+		c.autoLockTimer.Stop()
+		c.autoLockTimer = nil
+	}
+	if timeout > 0 {
+		c.autoLockTimer = time.AfterFunc(timeout, c.autoLockIfIdle)
+	}
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+func (c *Core) lockCalendar(name string) error {
+	cal, ok := c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	if cal.Locked {
+		return nil // already locked
+	}
+	if !cal.IsEncrypted() {
+		return errors.New("calendar is not encrypted")
+	}
+
+	// replace the stored key with a lock marker, so that the calendar stays locked after reload
+	marker, err := c.fs.Create(lockFileName(name))
+	if err != nil {
+		return fmt.Errorf("failed to create lock file: %w", err)
+	}
+	marker.Close()
+
+	if err := c.fs.Remove(keyFileName(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove key file: %w", err)
+	}
+
+	clear(cal.EncryptionKey) // overwrite the key bytes
+	cal.EncryptionKey = nil
+	cal.Locked = true
+	cal.Quarantine = nil
+	cal.Config = CalendarConfig{}
+
+	c.unloadCalendarEvents(name)
+	return nil
+}
+
+// Records an activity and restarts the auto-lock timer. If the Core was idle for longer than the auto-lock timeout,
+// locks all encrypted calendars first. Must be called without holding c.mu.
+func (c *Core) touch() {
+	now := time.Now()
+	last := time.Unix(0, c.lastActivity.Load())
+	timeout := time.Duration(c.autoLockTimeout.Load())
+	c.restartIdle()
+	if timeout > 0 && now.Sub(last) > timeout {
+		c.autoLockAll()
+	}
+}
+
+// Records an activity now and restarts the auto-lock timer, without checking the idle time.
+func (c *Core) restartIdle() {
+	c.lastActivity.Store(time.Now().UnixNano())
+	timeout := time.Duration(c.autoLockTimeout.Load())
+	if timeout <= 0 {
+		return
+	}
+
+	c.autoLockMu.Lock()
+	defer c.autoLockMu.Unlock()
+	if c.autoLockTimer != nil {
+		c.autoLockTimer.Reset(timeout)
+	}
+}
+
+// Called by the auto-lock timer. Locks all encrypted calendars, unless there was an activity meanwhile.
+func (c *Core) autoLockIfIdle() {
+	timeout := time.Duration(c.autoLockTimeout.Load())
+	last := time.Unix(0, c.lastActivity.Load())
+	if timeout <= 0 || time.Since(last) < timeout {
+		return // the timer fired while an operation was starting
+	}
+	c.autoLockAll()
+}
+
+// Locks all encrypted calendars and starts a new idle period, so that the next operation (e.g. an unlock)
+// doesn't lock them again. Must be called without holding c.mu.
+func (c *Core) autoLockAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.restartIdle()
+
+	for name, cal := range c.calendars {
+		if !cal.IsEncrypted() {
+			continue
+		}
+		if err := c.lockCalendar(name); err != nil {
+			c.logger.Error("failed to auto-lock calendar", "op", "autolock", "calendar", name, "error", err)
+		}
+	}
+}
+
+// Stores the key check of the calendar key in its metadata and commits it.
+func (c *Core) addKeyCheck(name string) error {
+	cal := c.calendars[name]
+	check, err := newKeyCheck(cal.EncryptionKey)
+	if err != nil {
+		return err
+	}
+	meta := cal.Metadata
+	meta.KeyCheck = check
+	return c.transaction(func(tx *transaction) error {
+		return tx.saveMetadata(name, meta, "Added password check")
+	})
+}
+
+// Removes all events of the calendar from the events map and the interval tree.
+func (c *Core) unloadCalendarEvents(name string) {
+	for id, event := range c.events {
+		if event.Calendar != name {
+			continue
+		}
+		if err := c.intervalTree.RemoveEvent(*event); err != nil {
+			c.logger.Error("failed to remove event from index tree", "op", "lock", "calendar", name, "event", id, "error", err)
+		}
+		delete(c.events, id)
+	}
+}
+
+// Returns the name of the marker file of a locked calendar.
+func lockFileName(name string) string {
+	return fmt.Sprintf("%s.locked", name)
+}
+
+// Returns whether the calendar was locked (and the marker file exists).
+func (c *Core) isLockedOnDisk(name string) bool {
+	_, err := c.fs.Stat(lockFileName(name))
+	return err == nil
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/google/uuid"
+)
+
+// An event file which couldn't be loaded (see Core.ListQuarantine).
+type QuarantinedFile struct {
+	Calendar string    `json:"calendar"`
+	Path     string    `json:"path"` // relative to the calendar repo root
+	Kind     IssueKind `json:"kind"`
+	Error    string    `json:"error"`
+	Raw      string    `json:"raw,omitzero"` // the file content, only for unencrypted calendars
+}
+
+// Returns all event files of loaded calendars, which couldn't be read, are invalid or belong to another calendar.
+func (c *Core) ListQuarantine() []QuarantinedFile {
+	c.touch()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	files := []QuarantinedFile{}
+	for _, name := range slices.Sorted(maps.Keys(c.calendars)) {
+		files = append(files, c.calendars[name].Quarantine...)
+	}
+	return files
+}
+
+// Deletes a quarantined file from the calendar and commits it.
+func (c *Core) RemoveQuarantined(calendar, filePath string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkQuarantined(calendar, filePath); err != nil {
+		return err
+	}
+
+	err := c.transaction(func(tx *transaction) error {
+		return tx.removeFile(calendar, filePath, fmt.Sprintf("Removed unreadable file '%s'", filePath))
+	})
+	if err != nil {
+		return err
+	}
+
+	c.dropQuarantined(calendar, filePath)
+	return nil
+}
+
+// Replaces a quarantined file with the fixed event and loads it. If the event has no id, the id from the file name is used.
+func (c *Core) ResolveQuarantined(calendar, filePath string, event Event) (*Event, error) {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkQuarantined(calendar, filePath); err != nil {
+		return nil, err
+	}
+
+	event.Calendar = calendar
+	if event.Id == uuid.Nil {
+		event.Id, _ = uuid.Parse(strings.TrimSuffix(path.Base(filePath), ".json")) // stays nil for bad file names
+	}
+
+	var resolved *Event
+	err := c.transaction(func(tx *transaction) (err error) {
+		if event.Id == uuid.Nil || eventGitPath(event.Id) != filePath {
+			// the event will be stored under a different name
+			if err := tx.removeFile(calendar, filePath, fmt.Sprintf("Removed unreadable file '%s'", filePath)); err != nil {
+				return err
+			}
+		}
+		resolved, err = c.createEvent(tx, event)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.dropQuarantined(calendar, filePath)
+	return resolved, nil
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Creates a quarantine entry for a file in the events directory. The content is only included if the calendar isn't encrypted.
+func newQuarantinedFile(eventsDir billy.Filesystem, calendar, filename string, kind IssueKind, err error, encrypted bool) QuarantinedFile {
+	file := QuarantinedFile{
+		Calendar: calendar,
+		Path:     path.Join(EventsDirName, filename),
+		Kind:     kind,
+		Error:    err.Error(),
+	}
+	if !encrypted {
+		raw, _ := readWholeFile(eventsDir, filename)
+		file.Raw = string(raw)
+	}
+	return file
+}
+
+// Returns an error if the file isn't quarantined (or the calendar can't be changed).
+func (c *Core) checkQuarantined(calendar, filePath string) error {
+	cal, ok := c.calendars[calendar]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", calendar, ErrNotFound)
+	}
+	if cal.Locked {
+		return fmt.Errorf("calendar '%s': %w", calendar, ErrLocked)
+	}
+
+	if !slices.ContainsFunc(cal.Quarantine, func(q QuarantinedFile) bool { return q.Path == filePath }) {
+		return fmt.Errorf("quarantined file '%s': %w", filePath, ErrNotFound)
+	}
+	return nil
+}
+
+// Removes the quarantine entry of the file.
+func (c *Core) dropQuarantined(calendar, filePath string) {
+	cal := c.calendars[calendar]
+	cal.Quarantine = slices.DeleteFunc(cal.Quarantine, func(q QuarantinedFile) bool { return q.Path == filePath })
+}
@@ -0,0 +1,328 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// A remote of a calendar repository, see Core.ListRemotes.
+type Remote struct {
+	Name string   `json:"name"`
+	URLs []string `json:"urls"` // Without credentials (see CredentialProvider).
+}
+
+// Adds a new remote to the specified calendar repository.
+func (c *Core) AddRemote(calendar, remoteName, remoteUrl string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.addRemote(calendar, remoteName, remoteUrl)
+}
+
+// Returns the remotes of the calendar repository, sorted by name.
+func (c *Core) ListRemotes(calendar string) ([]Remote, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cal, ok := c.calendars[calendar]
+	if !ok {
+		return nil, fmt.Errorf("calendar '%s': %w", calendar, ErrNotFound)
+	}
+	remotes, err := cal.Repository.Remotes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+
+	result := make([]Remote, 0, len(remotes))
+	for _, remote := range remotes {
+		cfg := remote.Config()
+		urls := make([]string, 0, len(cfg.URLs))
+		for _, u := range cfg.URLs {
+			urls = append(urls, withoutCredentials(u))
+		}
+		result = append(result, Remote{Name: cfg.Name, URLs: urls})
+	}
+	slices.SortFunc(result, func(a, b Remote) int { return strings.Compare(a.Name, b.Name) })
+	return result, nil
+}
+
+// Removes the remote (and its remote-tracking branches) from the calendar repository.
+// The branches tracking it don't track anything afterwards.
+func (c *Core) RemoveRemote(calendar, remoteName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateRemotes(calendar, func(cfg *config.Config) error {
+		if _, ok := cfg.Remotes[remoteName]; !ok {
+			return fmt.Errorf("remote '%s': %w", remoteName, ErrNotFound)
+		}
+		delete(cfg.Remotes, remoteName)
+
+		for name, branch := range cfg.Branches {
+			if branch.Remote != remoteName {
+				continue
+			}
+			branch.Remote, branch.Merge = "", ""
+			if branch.Rebase == "" && branch.Description == "" {
+				delete(cfg.Branches, name) // nothing left
+			}
+		}
+		return nil
+	}, func(repo *gogit.Repository) error {
+		return renameRemoteRefs(repo, remoteName, "")
+	})
+}
+
+// Renames the remote, including its remote-tracking branches and the branches tracking it.
+func (c *Core) RenameRemote(calendar, oldName, newName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateRemotes(calendar, func(cfg *config.Config) error {
+		remote, ok := cfg.Remotes[oldName]
+		if !ok {
+			return fmt.Errorf("remote '%s': %w", oldName, ErrNotFound)
+		}
+		if _, ok := cfg.Remotes[newName]; ok {
+			return fmt.Errorf("remote '%s': %w", newName, ErrAlreadyExists)
+		}
+
+		renamed := *remote
+		renamed.Name = newName
+		renamed.Fetch = nil
+		for _, spec := range remote.Fetch {
+			renamed.Fetch = append(renamed.Fetch, config.RefSpec(strings.ReplaceAll(
+				spec.String(), "refs/remotes/"+oldName+"/", "refs/remotes/"+newName+"/",
+			)))
+		}
+		if err := renamed.Validate(); err != nil {
+			return fmt.Errorf("invalid remote name: %w", err)
+		}
+		delete(cfg.Remotes, oldName)
+		cfg.Remotes[newName] = &renamed
+
+		for _, branch := range cfg.Branches {
+			if branch.Remote == oldName {
+				branch.Remote = newName
+			}
+		}
+		return nil
+	}, func(repo *gogit.Repository) error {
+		return renameRemoteRefs(repo, oldName, newName)
+	})
+}
+
+// Replaces the URL(s) of the remote.
+func (c *Core) SetRemoteURL(calendar, remoteName, remoteUrl string) error {
+	validUrl, err := validateRemoteUrl(remoteUrl)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateRemotes(calendar, func(cfg *config.Config) error {
+		remote, ok := cfg.Remotes[remoteName]
+		if !ok {
+			return fmt.Errorf("remote '%s': %w", remoteName, ErrNotFound)
+		}
+		remote.URLs = []string{validUrl}
+		return nil
+	}, nil)
+}
+
+// Parses the remote URL. The scp-like syntax of ssh remotes ("git@github.com:user/calendar.git") becomes an ssh:// URL.
+func ParseRemoteUrl(remoteUrl string) (*url.URL, error) {
+	u := strings.TrimSpace(remoteUrl)
+	if m := scpLikeUrlRegex.FindStringSubmatch(u); m != nil {
+		return &url.URL{Scheme: "ssh", User: url.User(m[1]), Host: m[2], Path: "/" + m[3]}, nil
+	}
+	return url.Parse(u)
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// The implementations of the public methods above, without locking.
+
+func (c *Core) addRemote(calendar, remoteName, remoteUrl string) error {
+	validUrl, err := validateRemoteUrl(remoteUrl)
+	if err != nil {
+		return err
+	}
+
+	cal, ok := c.calendars[calendar]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", calendar, ErrNotFound)
+	}
+	unlock, err := c.lockRepo(calendar)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = cal.Repository.CreateRemote(&config.RemoteConfig{
+		Name: remoteName,
+		URLs: []string{validUrl},
+	})
+	if errors.Is(err, gogit.ErrRemoteExists) {
+		return fmt.Errorf("remote '%s': %w", remoteName, ErrAlreadyExists)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create a remote: %w", err)
+	}
+
+	return nil
+}
+
+// Applies the change to the git config of the calendar repository and saves it. Then calls after (if not nil) with the repository.
+// Both run under the repository lock (see lockRepo).
+func (c *Core) updateRemotes(calendar string, change func(cfg *config.Config) error, after func(repo *gogit.Repository) error) error {
+	cal, ok := c.calendars[calendar]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", calendar, ErrNotFound)
+	}
+	unlock, err := c.lockRepo(calendar)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := cal.Repository.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read git config: %w", err)
+	}
+	if err := change(cfg); err != nil {
+		return err
+	}
+	if err := cal.Repository.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to write git config: %w", err)
+	}
+
+	if after != nil {
+		return after(cal.Repository)
+	}
+	return nil
+}
+
+// Moves the remote-tracking branches of the remote under the new name, or removes them if newName is empty.
+func renameRemoteRefs(repo *gogit.Repository, oldName, newName string) error {
+	refs, err := repo.References()
+	if err != nil {
+		return fmt.Errorf("failed to list references: %w", err)
+	}
+	defer refs.Close()
+
+	oldPrefix := "refs/remotes/" + oldName + "/"
+	var matched []*plumbing.Reference
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), oldPrefix) {
+			matched = append(matched, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list references: %w", err)
+	}
+
+	for _, ref := range matched {
+		if newName != "" {
+			name := plumbing.ReferenceName("refs/remotes/" + newName + "/" + strings.TrimPrefix(ref.Name().String(), oldPrefix))
+			var renamed *plumbing.Reference
+			if ref.Type() == plumbing.SymbolicReference {
+				target := strings.Replace(ref.Target().String(), oldPrefix, "refs/remotes/"+newName+"/", 1)
+				renamed = plumbing.NewSymbolicReference(name, plumbing.ReferenceName(target))
+			} else {
+				renamed = plumbing.NewHashReference(name, ref.Hash())
+			}
+			if err := repo.Storer.SetReference(renamed); err != nil {
+				return fmt.Errorf("failed to rename reference '%s': %w", ref.Name(), err)
+			}
+		}
+		if err := repo.Storer.RemoveReference(ref.Name()); err != nil {
+			return fmt.Errorf("failed to remove reference '%s': %w", ref.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Matches the scp-like syntax of ssh remotes, e.g. "git@github.com:user/calendar.git".
+var scpLikeUrlRegex = regexp.MustCompile(`^([\w.-]+)@([\w.-]+):([^/\s][^\s]*)$`)
+
+var errSshUnsupported = errors.New("ssh remotes are not supported in the browser, use https")
+
+// Checks the remote URL and returns it normalized (git doesn't check it when adding a remote, it fails afterwards with e.g. git fetch).
+// The ".git" suffix is optional, as some hosts don't use it. Secrets are rejected, the git config is plaintext.
+func validateRemoteUrl(remoteUrl string) (string, error) {
+	u := strings.TrimSuffix(strings.TrimSpace(remoteUrl), "/") // remove trailing "/"
+	if scpLikeUrlRegex.MatchString(u) {
+		if !sshSupported {
+			return "", errSshUnsupported
+		}
+		return u, nil
+	}
+
+	parsedUrl, err := url.Parse(u)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse remote url: %w", err)
+	}
+	switch parsedUrl.Scheme {
+	case "http", "https", "git":
+		if parsedUrl.Host == "" {
+			return "", fmt.Errorf("remote url '%s' has no host", u)
+		}
+		if parsedUrl.User != nil { // the username can be a token
+			return "", errors.New("remote url cannot contain credentials, use a CredentialProvider")
+		}
+	case "ssh":
+		if !sshSupported {
+			return "", errSshUnsupported
+		}
+		if parsedUrl.Host == "" {
+			return "", fmt.Errorf("remote url '%s' has no host", u)
+		}
+		if _, ok := parsedUrl.User.Password(); ok {
+			return "", errors.New("remote url cannot contain a password, use a CredentialProvider")
+		}
+	case "file":
+		if parsedUrl.Path == "" {
+			return "", fmt.Errorf("remote url '%s' has no path", u)
+		}
+	default:
+		return "", fmt.Errorf("remote url '%s' has an unsupported scheme (use http, https, ssh, git or file)", u)
+	}
+	return parsedUrl.String(), nil
+}
+
+// Removes the credentials older versions stored in the remote URLs (the ones used for the clone),
+// the CredentialProvider supplies them now. Returns whether the git config was changed.
+func removeUrlCredentials(repo *gogit.Repository) (bool, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return false, fmt.Errorf("failed to read git config: %w", err)
+	}
+	changed := false
+	for _, remote := range cfg.Remotes {
+		for i, u := range remote.URLs {
+			if stripped := withoutCredentials(u); stripped != u {
+				remote.URLs[i] = stripped
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	if err := repo.SetConfig(cfg); err != nil {
+		return false, fmt.Errorf("failed to write git config: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,315 @@
+package core
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/sideband"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gogitfs "github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// The progress of a remote operation as reported by the git server, e.g. "Counting objects: 45% (9/20)".
+type Progress struct {
+	Stage   string `json:"stage"` // e.g. "Counting objects", "Compressing objects"
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+}
+
+// The outcome of syncing a calendar with one of its remotes, see Core.PushAll and Core.PullAll.
+type SyncResult struct {
+	Calendar string     `json:"calendar"`
+	Remote   string     `json:"remote"`
+	Status   SyncStatus `json:"status"`
+	Error    string     `json:"error,omitzero"` // The error message, if the sync failed.
+	Err      error      `json:"-"`              // The error itself, to check it with errors.Is.
+}
+
+type SyncStatus string
+
+const (
+	SyncOk         SyncStatus = "ok"
+	SyncUpToDate   SyncStatus = "up_to_date"  // nothing to push or pull
+	SyncRejected   SyncStatus = "rejected"    // not a fast-forward, the remote has diverged (ErrConflict)
+	SyncAuthFailed SyncStatus = "auth_failed" // ErrAuthFailed
+	SyncNetwork    SyncStatus = "network"     // ErrNetwork
+	SyncFailed     SyncStatus = "failed"      // any other error
+)
+
+// Returns a context making the remote operations run with it (Fetch, Pull, Push, CloneCalendarContext) report their progress.
+// Report is called from the goroutine running the operation, so it must not block.
+func WithProgress(ctx context.Context, report func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// Downloads the commits of the remote ("" means origin) into the calendar repository, without changing the events.
+func (c *Core) Fetch(ctx context.Context, calendar, remote string) error {
+	return c.remoteOperation(ctx, calendar, remote, fetchRemote(ctx))
+}
+
+// Fetches from the remote ("" means origin) and merges it into the calendar, then reloads the calendars.
+// Events changed on both sides are merged field by field, the local changes win.
+// Fails with ErrUncommitted if the calendar has changed or untracked files (see CheckIntegrity and Repair).
+func (c *Core) Pull(ctx context.Context, calendar, remote string) error {
+	_, reloadErr, err := c.pull(ctx, calendar, remote)
+	return errors.Join(err, reloadErr)
+}
+
+// Uploads the commits of the calendar to the remote ("" means origin). If the remote has diverged,
+// it's fetched and merged (see Pull), and the push is retried up to MaxPushRetries times.
+func (c *Core) Push(ctx context.Context, calendar, remote string) error {
+	_, reloadErr, err := c.push(ctx, calendar, remote)
+	return errors.Join(err, reloadErr)
+}
+
+// Pushes every calendar to each of its remotes (see Push). Returns a result for each of them, sorted by the calendar and the remote.
+// The error is only about reloading the merged calendars.
+func (c *Core) PushAll() ([]SyncResult, error) {
+	results := []SyncResult{}
+	var reloadErrs error
+	for _, name := range c.calendarNames() {
+		remotes, err := c.remoteNames(name)
+		if err != nil {
+			results = append(results, newSyncResult(name, "", false, err))
+			continue
+		}
+		for _, remote := range remotes {
+			pushed, reloadErr, err := c.push(context.Background(), name, remote)
+			results = append(results, newSyncResult(name, remote, pushed, err))
+			reloadErrs = errors.Join(reloadErrs, reloadErr)
+		}
+	}
+	return results, reloadErrs
+}
+
+// Pulls every calendar with an origin remote (see Pull). Returns a result for each of them, sorted by the calendar.
+// The error is only about reloading the changed calendars.
+func (c *Core) PullAll() ([]SyncResult, error) {
+	results := []SyncResult{}
+	var reloadErrs error
+	for _, name := range c.calendarNames() {
+		remotes, err := c.remoteNames(name)
+		if err != nil || !slices.Contains(remotes, gogit.DefaultRemoteName) {
+			continue // a local calendar (or removed meanwhile)
+		}
+		pulled, reloadErr, err := c.pull(context.Background(), name, gogit.DefaultRemoteName)
+		results = append(results, newSyncResult(name, gogit.DefaultRemoteName, pulled, err))
+		reloadErrs = errors.Join(reloadErrs, reloadErr)
+	}
+	return results, reloadErrs
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// The implementations of the public methods above. Unlike other helpers they take the locks themselves:
+// the network part holds only the repository lease (see remoteOperation), so that the other calendars
+// can be used meanwhile, the merge holds the Core lock too (see mergeFetched).
+
+// Fetches and merges the remote (see mergeRemote). Returns whether the calendar changed,
+// and the error of reloading the calendars after the change, if any.
+func (c *Core) pull(ctx context.Context, calendar, remote string) (pulled bool, reloadErr, err error) {
+	remote = cmp.Or(remote, gogit.DefaultRemoteName)
+	if err := c.remoteOperation(ctx, calendar, remote, fetchRemote(ctx)); err != nil {
+		return false, nil, err
+	}
+	return c.mergeFetched(calendar, remote)
+}
+
+// Returns whether anything was pushed, and the error of reloading the calendars after merging the remote
+// (if a push was rejected), if any.
+func (c *Core) push(ctx context.Context, calendar, remote string) (pushed bool, reloadErr, err error) {
+	remote = cmp.Or(remote, gogit.DefaultRemoteName)
+	for attempt := 0; ; attempt++ {
+		err = c.remoteOperation(ctx, calendar, remote, func(repo *gogit.Repository, opts remoteOptions) error {
+			return repo.PushContext(ctx, &gogit.PushOptions{RemoteName: opts.remote, Auth: opts.auth, Progress: opts.progress})
+		})
+		if attempt == MaxPushRetries || !errors.Is(err, ErrConflict) {
+			break
+		}
+		c.Logger().Info("push rejected, merging the remote", "op", "push", "calendar", calendar, "remote", remote, "attempt", attempt+1)
+
+		if _, reloadErr, err = c.pull(ctx, calendar, remote); err != nil || reloadErr != nil {
+			return false, reloadErr, err
+		}
+	}
+	if errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return false, reloadErr, nil
+	}
+	return err == nil, reloadErr, err
+}
+
+// Returns the operation fetching the remote. An empty remote or one without new commits isn't an error.
+func fetchRemote(ctx context.Context) func(repo *gogit.Repository, opts remoteOptions) error {
+	return func(repo *gogit.Repository, opts remoteOptions) error {
+		err := repo.FetchContext(ctx, &gogit.FetchOptions{RemoteName: opts.remote, Auth: opts.auth, Progress: opts.progress})
+		if errors.Is(err, gogit.NoErrAlreadyUpToDate) || errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return nil
+		}
+		return err
+	}
+}
+
+// Merges the fetched remote into the calendar (even if nothing new was fetched, an earlier Fetch may not be merged yet)
+// and reloads the calendars, if it changed. Returns whether it changed and the error of the reload.
+func (c *Core) mergeFetched(calendar, remote string) (changed bool, reloadErr, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cal, ok := c.calendars[calendar]
+	if !ok {
+		return false, nil, fmt.Errorf("calendar '%s': %w", calendar, ErrNotFound)
+	}
+	unlock, err := c.lockRepo(calendar)
+	if err != nil {
+		return false, nil, err
+	}
+	defer unlock()
+
+	// the fetch may have run on another instance of the repository (if the calendars were reloaded meanwhile),
+	// which the cached list of packfiles doesn't know about
+	if storage, ok := cal.Repository.Storer.(*gogitfs.Storage); ok {
+		storage.Reindex()
+	}
+	if changed, err = c.mergeRemote(calendar, remote); err != nil || !changed {
+		return false, nil, err
+	}
+	c.notifyChange(calendar)
+	return true, c.loadCalendars(), nil
+}
+
+// Returns the names of the calendars, sorted.
+func (c *Core) calendarNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(c.calendars))
+}
+
+// Returns the names of the remotes of the calendar, sorted.
+func (c *Core) remoteNames(calendar string) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cal, ok := c.calendars[calendar]
+	if !ok {
+		return nil, fmt.Errorf("calendar '%s': %w", calendar, ErrNotFound)
+	}
+	remotes, err := cal.Repository.Remotes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	names := make([]string, 0, len(remotes))
+	for _, remote := range remotes {
+		names = append(names, remote.Config().Name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Returns the result of a push or pull, which changed something (or not).
+func newSyncResult(calendar, remote string, changed bool, err error) SyncResult {
+	result := SyncResult{Calendar: calendar, Remote: remote, Err: err}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	switch {
+	case err == nil && changed:
+		result.Status = SyncOk
+	case err == nil:
+		result.Status = SyncUpToDate
+	case errors.Is(err, ErrConflict):
+		result.Status = SyncRejected
+	case errors.Is(err, ErrAuthFailed):
+		result.Status = SyncAuthFailed
+	case errors.Is(err, ErrNetwork):
+		result.Status = SyncNetwork
+	default:
+		result.Status = SyncFailed
+	}
+	return result
+}
+
+// What a remote operation needs besides the repository.
+type remoteOptions struct {
+	remote   string // never empty
+	auth     transport.AuthMethod
+	progress sideband.Progress // nil if nobody listens, so that the server doesn't send it
+}
+
+// Runs the network part of a remote operation on the calendar repository while holding (and renewing) its repository lease,
+// but not the Core lock. Errors are wrapped by remoteError; NoErrAlreadyUpToDate is returned as it is.
+func (c *Core) remoteOperation(ctx context.Context, calendar, remote string, op func(repo *gogit.Repository, opts remoteOptions) error) error {
+	remote = cmp.Or(remote, gogit.DefaultRemoteName)
+
+	c.mu.RLock()
+	cal, ok := c.calendars[calendar]
+	if !ok {
+		c.mu.RUnlock()
+		return fmt.Errorf("calendar '%s': %w", calendar, ErrNotFound)
+	}
+	remoteConfig, err := cal.Repository.Remote(remote)
+	repo, provider, logger := cal.Repository, c.credentials, c.logger
+	c.mu.RUnlock()
+
+	if errors.Is(err, gogit.ErrRemoteNotFound) {
+		return fmt.Errorf("remote '%s' of calendar '%s': %w", remote, calendar, ErrNotFound)
+	}
+	var auth transport.AuthMethod
+	if err == nil && len(remoteConfig.Config().URLs) != 0 {
+		if auth, err = askCredentials(provider, calendar, remote, remoteConfig.Config().URLs[0]); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := c.leaseRepo(calendar, logger)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer c.keepLease(calendar, logger)() // the network can take longer than RepoLeaseTTL
+
+	err = op(repo, remoteOptions{remote: remote, auth: auth, progress: progressOf(ctx)})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("calendar '%s', remote '%s': %w", calendar, remote, remoteError(err))
+	}
+	return err
+}
+
+type progressKey struct{}
+
+// Returns the writer reporting the progress to the function from WithProgress, or nil if the context has none.
+func progressOf(ctx context.Context) sideband.Progress {
+	report, ok := ctx.Value(progressKey{}).(func(Progress))
+	if !ok || report == nil {
+		return nil
+	}
+	return &progressWriter{report: report}
+}
+
+// Turns the progress messages of the git server into Progress reports.
+// The messages come in chunks, lines end with "\r" (an update of the same stage) or "\n".
+type progressWriter struct {
+	report  func(Progress)
+	pending []byte // an unfinished line
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexAny(w.pending, "\r\n")
+		if i < 0 {
+			break
+		}
+		if progress, ok := parseProgress(string(w.pending[:i])); ok {
+			w.report(progress)
+		}
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Returns the tags of the calendar (see CalendarConfig.Tags).
+func (c *Core) ListTags(calendar string) ([]Tag, error) {
+	c.touch()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cal, err := c.unlockedCalendar(calendar)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(cal.Config.Tags), nil
+}
+
+// Adds a new tag to the calendar.
+func (c *Core) CreateTag(calendar string, tag Tag) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateTags(calendar, fmt.Sprintf("Added tag '%s'", tag.Name), func(cfg *CalendarConfig) error {
+		if cfg.tagIndex(tag.Name) != -1 {
+			return fmt.Errorf("tag '%s': %w", tag.Name, ErrAlreadyExists)
+		}
+		cfg.Tags = append(cfg.Tags, tag)
+		return nil
+	}, nil)
+}
+
+// Updates the color, icon and description of the tag with the same name. Use RenameTag to change the name.
+func (c *Core) UpdateTag(calendar string, tag Tag) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateTags(calendar, fmt.Sprintf("Updated tag '%s'", tag.Name), func(cfg *CalendarConfig) error {
+		i := cfg.tagIndex(tag.Name)
+		if i == -1 {
+			return fmt.Errorf("tag '%s': %w", tag.Name, ErrNotFound)
+		}
+		cfg.Tags[i] = tag
+		return nil
+	}, nil)
+}
+
+// Removes the tag from the calendar and from the categories of all its events.
+func (c *Core) RemoveTag(calendar, name string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateTags(calendar, fmt.Sprintf("Removed tag '%s'", name), func(cfg *CalendarConfig) error {
+		i := cfg.tagIndex(name)
+		if i == -1 {
+			return fmt.Errorf("tag '%s': %w", name, ErrNotFound)
+		}
+		cfg.Tags = slices.Delete(cfg.Tags, i, i+1)
+		return nil
+	}, func(tag string) string {
+		if tag == name {
+			return ""
+		}
+		return tag
+	})
+}
+
+// Renames the tag, including the categories of all the events using it, in a single commit.
+func (c *Core) RenameTag(calendar, oldName, newName string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateTags(calendar, fmt.Sprintf("Renamed tag '%s' to '%s'", oldName, newName), func(cfg *CalendarConfig) error {
+		i := cfg.tagIndex(oldName)
+		if i == -1 {
+			return fmt.Errorf("tag '%s': %w", oldName, ErrNotFound)
+		}
+		if cfg.tagIndex(newName) != -1 {
+			return fmt.Errorf("tag '%s': %w", newName, ErrAlreadyExists)
+		}
+		cfg.Tags[i].Name = newName
+		return nil
+	}, func(tag string) string {
+		if tag == oldName {
+			return newName
+		}
+		return tag
+	})
+}
+
+// Replaces the source tags by the target tag in the categories of all the events and removes them from the calendar, in a single commit.
+func (c *Core) MergeTags(calendar string, sources []string, target string) error {
+	c.touch()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sources = slices.DeleteFunc(slices.Clone(sources), func(s string) bool { return s == target })
+	if len(sources) == 0 {
+		return nil // nothing to do
+	}
+
+	return c.updateTags(calendar, fmt.Sprintf("Merged tags into '%s'", target), func(cfg *CalendarConfig) error {
+		if cfg.tagIndex(target) == -1 {
+			return fmt.Errorf("tag '%s': %w", target, ErrNotFound)
+		}
+		for _, source := range sources {
+			if cfg.tagIndex(source) == -1 {
+				return fmt.Errorf("tag '%s': %w", source, ErrNotFound)
+			}
+		}
+		cfg.Tags = slices.DeleteFunc(cfg.Tags, func(t Tag) bool { return slices.Contains(sources, t.Name) })
+		return nil
+	}, func(tag string) string {
+		if slices.Contains(sources, tag) {
+			return target
+		}
+		return tag
+	})
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Returns the calendar, if it exists and is unlocked.
+func (c *Core) unlockedCalendar(name string) (*Calendar, error) {
+	cal, ok := c.calendars[name]
+	if !ok {
+		return nil, fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	if cal.Locked {
+		return nil, fmt.Errorf("calendar '%s': %w", name, ErrLocked)
+	}
+	return cal, nil
+}
+
+// Changes the tags in a copy of the calendar config and saves it, rewriting the categories of the events by retag (if not nil) in the same commit.
+// Retag returns the new name of a category, or "" to drop it.
+func (c *Core) updateTags(name, commitMsg string, change func(cfg *CalendarConfig) error, retag func(tag string) string) error {
+	cal, err := c.unlockedCalendar(name)
+	if err != nil {
+		return err
+	}
+
+	cfg := cal.Config.clone()
+	if err := change(&cfg); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid tags: %w", err)
+	}
+
+	return c.transaction(func(tx *transaction) error {
+		if err := tx.saveConfig(name, cfg, commitMsg); err != nil {
+			return err
+		}
+		if retag == nil {
+			return nil
+		}
+		for _, event := range c.eventsOfCalendar(name) {
+			categories := retagged(event.Categories, retag)
+			if slices.Equal(categories, event.Categories) {
+				continue
+			}
+			updated := event.clone()
+			updated.Categories = categories
+			if err := tx.replaceEvent(event, &updated, ""); err != nil { // covered by the config commit message
+				return fmt.Errorf("failed to retag event '%s': %w", event.Id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Checks that the categories of the event are its calendar's tags. Calendars without any tags allow any categories.
+func (c *Core) checkCategories(event *Event) error {
+	cal, ok := c.calendars[event.Calendar]
+	if !ok || len(cal.Config.Tags) == 0 {
+		return nil // a missing calendar is reported by the caller
+	}
+	for _, category := range event.Categories {
+		if cal.Config.tagIndex(category) == -1 {
+			return fmt.Errorf("%w: unknown tag '%s' in calendar '%s'", ErrInvalidEvent, category, event.Calendar)
+		}
+	}
+	return nil
+}
+
+// Returns the categories renamed by retag, without the dropped ones and duplicates (e.g. after a merge).
+func retagged(categories []string, retag func(tag string) string) []string {
+	var result []string
+	for _, category := range categories {
+		if renamed := retag(category); renamed != "" && !slices.Contains(result, renamed) {
+			result = append(result, renamed)
+		}
+	}
+	return result
+}
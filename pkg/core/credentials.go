@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// The credentials for a git remote. For http(s) either a username with a password (or an access token used as the password),
+// or a bearer token sent in the Authorization header. For ssh (not supported in the browser) a private key.
+type Credentials struct {
+	Username   string `json:"username,omitzero"`    // For ssh only if the url has none; default is "git".
+	Password   string `json:"password,omitzero"`    // For ssh the passphrase of an encrypted private key.
+	Token      string `json:"token,omitzero"`       // Takes precedence over Username and Password.
+	PrivateKey string `json:"private_key,omitzero"` // PEM encoded ssh private key.
+	KnownHosts string `json:"known_hosts,omitzero"` // Lines of a known_hosts file verifying the ssh server; empty means ~/.ssh/known_hosts.
+}
+
+// Supplies the credentials for git remotes, implemented by the app (a keychain, a login dialog...).
+// It's asked before every clone, pull and push, and nothing it returns is stored by the Core.
+type CredentialProvider interface {
+	// Returns the credentials for the remote of the calendar, or nil for anonymous access.
+	// The url never contains a password (nor a token).
+	Credentials(calendar, remoteName, remoteUrl string) (*Credentials, error)
+}
+
+// Sets the provider asked for the credentials of git remotes. Nil means anonymous access.
+// The credentials older versions stored in the remote urls are removed when the calendars are loaded.
+func (c *Core) SetCredentialProvider(provider CredentialProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.credentials = provider
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Asks the credential provider (if there's one) for the credentials and converts them to the auth of go-git.
+// It's called without holding the Core lock, the provider may wait for the user.
+func askCredentials(provider CredentialProvider, calendar, remoteName, remoteUrl string) (transport.AuthMethod, error) {
+	parsedUrl, err := ParseRemoteUrl(remoteUrl)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse remote url: %w", err)
+	}
+	isSsh := parsedUrl.Scheme == "ssh"
+	if isSsh && !sshSupported {
+		return nil, errSshUnsupported
+	}
+	if provider == nil {
+		return nil, nil
+	}
+
+	creds, err := provider.Credentials(calendar, remoteName, withoutCredentials(remoteUrl))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get credentials for remote '%s': %w", ErrAuthFailed, remoteName, err)
+	}
+	switch {
+	case creds == nil:
+		return nil, nil
+	case isSsh:
+		return sshAuth(parsedUrl, creds)
+	case creds.Token != "":
+		return &http.TokenAuth{Token: creds.Token}, nil
+	case creds.Username != "" || creds.Password != "":
+		return &http.BasicAuth{Username: creds.Username, Password: creds.Password}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Removes the secrets from the URL: the user info of http(s) URLs (the username can be a token), the password of the others.
+func withoutCredentials(remoteUrl string) string {
+	parsedUrl, err := url.Parse(remoteUrl)
+	if err != nil || parsedUrl.User == nil {
+		return remoteUrl // scp-like, only a username
+	}
+	if parsedUrl.Scheme == "http" || parsedUrl.Scheme == "https" {
+		parsedUrl.User = nil
+	} else {
+		parsedUrl.User = url.User(parsedUrl.User.Username())
+	}
+	return parsedUrl.String()
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Errors returned by Core. They are always wrapped with more details, check them with errors.Is.
+var (
+	ErrNotFound      = errors.New("not found")             // a calendar, an event or a file doesn't exist
+	ErrAlreadyExists = errors.New("already exists")        // a calendar, an event or a remote with the same name/id exists
+	ErrInvalidEvent  = errors.New("invalid event")         // the event doesn't pass Event.Validate (or doesn't fit the operation)
+	ErrWrongPassword = errors.New("wrong password")        // the events can't be decrypted with the password
+	ErrLocked        = errors.New("calendar is locked")    // the calendar has to be unlocked first
+	ErrConflict      = errors.New("conflict")              // the remote has diverged
+	ErrNetwork       = errors.New("network error")         // the remote can't be reached
+	ErrAuthFailed    = errors.New("authentication failed") // the remote refused the credentials (or there are none)
+	ErrReadOnly      = errors.New("read-only")             // the storage can't be written to
+	ErrBusy          = errors.New("busy")                  // another instance (process, browser tab) is writing into the calendar
+	ErrUncommitted   = errors.New("uncommitted changes")   // a pull would discard files of the worktree which aren't committed
+)
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Wraps an error of a remote operation (clone/pull/push) with ErrConflict, ErrAuthFailed or ErrNetwork, if it's one of them.
+func remoteError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, gogit.ErrNonFastForwardUpdate),
+		errors.Is(err, gogit.ErrForceNeeded),
+		strings.Contains(err.Error(), gogit.ErrNonFastForwardUpdate.Error()): // a rejected push isn't a typed error
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		isSshAuthError(err):
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	case errors.As(err, &netErr),
+		errors.As(err, &urlErr),
+		errors.Is(err, transport.ErrRepositoryNotFound):
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	default:
+		return err
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"time"
 
@@ -26,10 +27,12 @@ type Event struct {
 	Description string      `json:"description,omitzero"`
 	From        time.Time   `json:"from,omitzero"`
 	To          time.Time   `json:"to,omitzero"`
-	Calendar    string      `json:"calendar,omitzero"`  // The name of the calendar the event belongs to.
-	Tag         string      `json:"tag,omitzero"`       // User-defined category or label.
-	ParentId    uuid.UUID   `json:"parent_id,omitzero"` // Specific for child events. It is uuid.Nil if the event is basic or parent.
+	Calendar    string      `json:"calendar,omitzero"`   // The name of the calendar the event belongs to.
+	Categories  []string    `json:"categories,omitzero"` // User-defined categories (the iCalendar CATEGORIES), names of the calendar tags.
+	ParentId    uuid.UUID   `json:"parent_id,omitzero"`  // Specific for child events. It is uuid.Nil if the event is basic or parent.
 	Repeat      *Repetition `json:"repeat,omitzero"`
+
+	DetachedFrom uuid.UUID `json:"detached_from,omitzero"` // The parent of the series this event was detached from (by updating only the current child). Not set by older versions.
 }
 
 // Repetition defines the recurrence rules for a Parent event.
@@ -65,6 +68,14 @@ func (e *Event) Validate() error {
 	if e.From.Compare(e.To) != -1 {
 		return errors.New("From timestamp cannot be greater or equal than To (cannot end before it starts)")
 	}
+	for i, category := range e.Categories {
+		if strings.TrimSpace(category) == "" {
+			return errors.New("category cannot be empty")
+		}
+		if slices.Index(e.Categories, category) != i {
+			return fmt.Errorf("duplicate category '%s'", category)
+		}
+	}
 	if err := e.Repeat.Validate(); err != nil {
 		return fmt.Errorf("repetition is invalid: %w", err)
 	}
@@ -91,6 +102,29 @@ func (r *Repetition) Validate() error {
 	return nil
 }
 
+// Returns a deep copy of the event (the repetition rule and its exceptions are copied as well).
+func (e Event) clone() Event {
+	if e.Repeat != nil {
+		repeat := *e.Repeat
+		repeat.Exceptions = slices.Clone(e.Repeat.Exceptions)
+		e.Repeat = &repeat
+	}
+	e.Categories = slices.Clone(e.Categories)
+	return e
+}
+
+// Reports whether the event has any (or all, if matchAll) of the categories. No categories match every event.
+func (e Event) HasCategories(categories []string, matchAll bool) bool {
+	if len(categories) == 0 {
+		return true
+	}
+	has := func(category string) bool { return slices.Contains(e.Categories, category) }
+	if matchAll {
+		return !slices.ContainsFunc(categories, func(c string) bool { return !has(c) })
+	}
+	return slices.ContainsFunc(categories, has)
+}
+
 func (e Event) IsBasic() bool {
 	return !e.IsChild() && !e.IsParent() // e.ParentId == uuid.Nil && e.Repeat == nil
 }
@@ -104,6 +138,7 @@ func (e Event) IsParent() bool {
 }
 
 // Returns either the To time.Time for Basic non-repeating event, or calculates the last occurrence of a repeating Parent event and returns its To.
+// For an Until series it's the end of the occurrence starting at Until, so the interval isn't empty when Until equals From.
 func (e Event) getTreeEndTime() time.Time {
 	if e.Repeat == nil {
 		return e.To
@@ -111,15 +146,16 @@ func (e Event) getTreeEndTime() time.Time {
 
 	eventEnd := e.To
 	if e.Repeat != nil {
-		eventEnd = e.Repeat.Until // if repeating, use interval [From, Repetition.Until]
-		if e.Repeat.Count >= 1 {  // if repeating on count basis
+		eventEnd = e.Repeat.Until.Add(e.To.Sub(e.From)) // if repeating, use interval [From, end of the occurrence starting at Repetition.Until]
+		if e.Repeat.Count >= 1 {                        // if repeating on count basis
 			eventEnd = addUnit(e.To, e.Repeat.Interval*e.Repeat.Count, e.Repeat.Frequency)
 		}
 	}
 	return eventEnd
 }
 
-func (e Event) WriteToFile(file billy.File, key []byte) error {
+// Writes the event as JSON into the file. If key is set, values are encrypted according to the calendar metadata (mode and plaintext fields).
+func (e Event) WriteToFile(file billy.File, key []byte, meta Metadata) error {
 	// not needed to be stored in the file
 	id := e.Id
 	e.Id = uuid.Nil
@@ -145,11 +181,15 @@ func (e Event) WriteToFile(file billy.File, key []byte) error {
 		return err
 	}
 
-	// encrypt everything recursively
-	encData, err := encryption.EncryptFields(data, key, id[:])
+	// keep the fields from the calendar policy unencrypted
+	plainData := meta.splitPlaintext(data)
+
+	// encrypt everything else recursively
+	encData, err := encryption.EncryptFieldsWithMode(data, key, id[:], meta.EncryptionMode)
 	if err != nil {
 		return err
 	}
+	mergeFields(encData.(map[string]any), plainData)
 
 	// marshal again
 	finalRaw, err := json.MarshalIndent(encData, "", "  ")
@@ -161,7 +201,8 @@ func (e Event) WriteToFile(file billy.File, key []byte) error {
 	return err
 }
 
-func (e *Event) LoadFromFile(file billy.File, decryptionKey []byte) error {
+// Reads the event from the file. If decryptionKey is set, values are decrypted according to the calendar metadata (mode and plaintext fields).
+func (e *Event) LoadFromFile(file billy.File, decryptionKey []byte, meta Metadata) error {
 	raw, err := io.ReadAll(file)
 	if err != nil {
 		return fmt.Errorf("failed to read file '%s': %w\n", file.Name(), err)
@@ -172,8 +213,15 @@ func (e *Event) LoadFromFile(file billy.File, decryptionKey []byte) error {
 		return fmt.Errorf("file name is not UUID.json but '%s': %w\n", file.Name(), err)
 	}
 
+	return e.decode(raw, decryptionKey, meta)
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Decodes the event from the file content (see LoadFromFile). The Id has to be set, it's used for the decryption.
+func (e *Event) decode(raw, decryptionKey []byte, meta Metadata) error {
 	if len(decryptionKey) == 0 { // no encryption, just use the plaintext
-		return json.Unmarshal(raw, e)
+		return e.unmarshal(raw)
 	}
 
 	var encryptedData map[string]any
@@ -181,14 +229,35 @@ func (e *Event) LoadFromFile(file billy.File, decryptionKey []byte) error {
 		return err
 	}
 
-	decryptedData, err := encryption.DecryptFields(encryptedData, decryptionKey, e.Id[:])
+	plainData := meta.splitPlaintext(encryptedData)
+
+	decryptedData, err := encryption.DecryptFieldsWithMode(encryptedData, decryptionKey, e.Id[:], meta.EncryptionMode)
 	if err != nil {
 		return err
 	}
+	mergeFields(decryptedData.(map[string]any), plainData)
 
 	// eww (map to struct)
-	tmp, err1 := json.Marshal(decryptedData)
-	err2 := json.Unmarshal(tmp, e)
+	tmp, err := json.Marshal(decryptedData)
+	if err != nil {
+		return err
+	}
+	return e.unmarshal(tmp)
+}
 
-	return errors.Join(err1, err2)
+// Unmarshals the event from its file JSON. The single "tag" of the older format becomes the first category.
+func (e *Event) unmarshal(raw []byte) error {
+	var legacy struct {
+		Tag string `json:"tag"`
+	}
+	if err := json.Unmarshal(raw, e); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, &legacy); err != nil {
+		return err
+	}
+	if legacy.Tag != "" && !slices.Contains(e.Categories, legacy.Tag) {
+		e.Categories = append([]string{legacy.Tag}, e.Categories...)
+	}
+	return nil
 }
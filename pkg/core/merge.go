@@ -0,0 +1,410 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/uuid"
+)
+
+// Merges the fetched branch of the remote into the current branch of the calendar. Returns whether HEAD changed.
+// The caller holds the repository lease and reloads the calendars.
+//
+// The branch is fast-forwarded if possible, otherwise a merge commit is created:
+//   - a file changed on one side only gets that change, a deleted file loses to a modified one,
+//   - an event changed on both sides is merged field by field (the local value wins if both changed the same field);
+//     if the result isn't a valid event, the local version is kept,
+//   - any other file changed on both sides keeps the local version.
+//
+// The events of each side are decoded with the metadata of that side and encoded with the resulting metadata
+// (see MetadataFileName), so a change of the encryption settings on either side is merged as well.
+// Fails with ErrConflict if the sides are encrypted with different keys
+// and with ErrUncommitted if the worktree has changes the merge would discard (see checkWorktreeClean).
+func (c *Core) mergeRemote(name, remote string) (bool, error) {
+	cal, ok := c.calendars[name]
+	if !ok {
+		return false, fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	repo := cal.Repository
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return false, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return false, errors.New("HEAD is not on a branch")
+	}
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, head.Target().Short()), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return false, nil // the remote doesn't have the branch (yet)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read the remote branch: %w", err)
+	}
+
+	local, theirs := repoHead(repo), remoteRef.Hash()
+	if local == theirs {
+		return false, nil
+	}
+	base := plumbing.ZeroHash
+	if !local.IsZero() {
+		if base, err = mergeBase(repo, local, theirs); err != nil {
+			return false, err
+		}
+	}
+	if base == theirs {
+		return false, nil // only local commits
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := checkWorktreeClean(wt); err != nil {
+		return false, err
+	}
+	if base == local {
+		if err := wt.Reset(&gogit.ResetOptions{Commit: theirs, Mode: gogit.HardReset}); err != nil {
+			return false, fmt.Errorf("failed to fast-forward: %w", err)
+		}
+		return true, nil
+	}
+
+	meta, err := c.mergeTrees(name, wt, base, local, theirs)
+	if err == nil {
+		_, err = wt.Commit(meta.commitMessage(fmt.Sprintf("Merged remote '%s'", remote)), &gogit.CommitOptions{
+			Author:            c.signature(),
+			Parents:           []plumbing.Hash{local, theirs},
+			AllowEmptyCommits: true, // the local versions could win everywhere, it still has to be a merge
+		})
+	}
+	if err != nil {
+		if resetErr := wt.Reset(&gogit.ResetOptions{Commit: local, Mode: gogit.HardReset}); resetErr != nil {
+			c.logger.Error("failed to reset an unfinished merge", "op", "merge", "calendar", name, "error", resetErr)
+		}
+		return false, fmt.Errorf("failed to merge remote '%s': %w", remote, err)
+	}
+	return true, nil
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Writes the merge of the theirs commit into the worktree (which is at the local commit) and stages it.
+// Returns the metadata of the result.
+func (c *Core) mergeTrees(name string, wt *gogit.Worktree, base, local, theirs plumbing.Hash) (Metadata, error) {
+	cal := c.calendars[name]
+	repo := cal.Repository
+
+	trees := make([]map[string]plumbing.Hash, 3)
+	metas := make([]Metadata, 3)
+	for i, hash := range []plumbing.Hash{base, local, theirs} {
+		files, err := treeFiles(repo, hash)
+		if err != nil {
+			return Metadata{}, err
+		}
+		if metas[i], err = treeMetadata(repo, files); err != nil {
+			return Metadata{}, err
+		}
+		trees[i] = files
+	}
+	baseFiles, localFiles, theirFiles := trees[0], trees[1], trees[2]
+	localMeta, theirMeta := metas[1], metas[2]
+
+	// the same rule as for the files below
+	resultMeta := localMeta
+	if b, l, r := baseFiles[MetadataFileName], localFiles[MetadataFileName], theirFiles[MetadataFileName]; l != r && r != b && (l == b || (l.IsZero() && !r.IsZero())) {
+		resultMeta = theirMeta
+	}
+	if err := checkMergeable(cal, metas, resultMeta); err != nil {
+		return Metadata{}, err
+	}
+	// returns how to write the file of the side encoded with the meta, nil if it can stay as it is
+	reencode := func(p string, hash plumbing.Hash, meta Metadata) func(file billy.File) error {
+		id, ok := eventFileId(p)
+		if !ok || len(cal.EncryptionKey) == 0 || meta.sameEncoding(resultMeta) {
+			return nil
+		}
+		return func(file billy.File) error {
+			raw, err := readBlob(repo, hash)
+			if err != nil {
+				return err
+			}
+			event := Event{Id: id}
+			if err := event.decode(raw, cal.EncryptionKey, meta); err != nil {
+				return fmt.Errorf("failed to decode event: %w", err)
+			}
+			return event.WriteToFile(file, cal.EncryptionKey, resultMeta)
+		}
+	}
+
+	paths := slices.Collect(maps.Keys(localFiles))
+	for p := range theirFiles {
+		if _, ok := localFiles[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+
+	for _, p := range paths {
+		b, l, r := baseFiles[p], localFiles[p], theirFiles[p]
+
+		var write func(file billy.File) error // nil removes the file
+		switch {
+		case l == r || r == b: // the same on both sides, or changed only locally
+			if l.IsZero() {
+				continue
+			}
+			if write = reencode(p, l, localMeta); write == nil {
+				continue
+			}
+		case l == b, l.IsZero(): // changed only by them, or deleted locally and modified by them
+			if !r.IsZero() {
+				if write = reencode(p, r, theirMeta); write == nil {
+					write = func(file billy.File) error { return writeBlob(repo, r, file) }
+				}
+			}
+		case r.IsZero(): // modified locally, deleted by them
+			if write = reencode(p, l, localMeta); write == nil {
+				continue
+			}
+		default:
+			merged, err := c.mergeEventFile(cal, p, [3]plumbing.Hash{b, l, r}, metas)
+			if err != nil {
+				c.logger.Warn("kept the local version of a file changed on both sides", "op", "merge", "calendar", name, "file", p, "error", err)
+				if write = reencode(p, l, localMeta); write == nil {
+					continue
+				}
+				break
+			}
+			write = func(file billy.File) error { return merged.WriteToFile(file, cal.EncryptionKey, resultMeta) }
+		}
+
+		fullPath := c.fs.Join(name, p)
+		if write == nil {
+			if err := c.fs.Remove(fullPath); err != nil {
+				return Metadata{}, fmt.Errorf("failed to remove '%s': %w", p, err)
+			}
+			if _, err := wt.Remove(p); err != nil {
+				return Metadata{}, fmt.Errorf("git rm: %w", err)
+			}
+			continue
+		}
+		if err := c.writeFileAtomic(fullPath, write); err != nil {
+			return Metadata{}, fmt.Errorf("failed to write '%s': %w", p, err)
+		}
+		if _, err := wt.Add(p); err != nil {
+			return Metadata{}, fmt.Errorf("git add: %w", err)
+		}
+	}
+	return resultMeta, nil
+}
+
+// Fails with ErrConflict if the events of a side (with the metadata) can't be re-encoded with the resulting metadata:
+// the side is encrypted with another key, or the calendar is locked.
+func checkMergeable(cal *Calendar, metas []Metadata, resultMeta Metadata) error {
+	for _, meta := range metas {
+		if len(cal.EncryptionKey) != 0 && !meta.checkKey(cal.EncryptionKey) {
+			return fmt.Errorf("%w: the remote is encrypted with another password", ErrConflict)
+		}
+		if cal.Locked && !meta.sameEncoding(resultMeta) {
+			return fmt.Errorf("%w: the encryption settings changed, unlock the calendar to merge", ErrConflict)
+		}
+	}
+	return nil
+}
+
+// Merges an event file changed on both sides field by field. Each side is decoded with its own metadata.
+// Fails if the file isn't an event, it can't be decoded (e.g. the calendar is locked) or the result isn't valid.
+func (c *Core) mergeEventFile(cal *Calendar, p string, hashes [3]plumbing.Hash, metas []Metadata) (*Event, error) {
+	id, ok := eventFileId(p)
+	if !ok {
+		return nil, errors.New("not an event file")
+	}
+	if cal.Locked {
+		return nil, ErrLocked
+	}
+
+	fields := make([]map[string]any, 3)
+	for i, hash := range hashes {
+		var err error
+		if fields[i], err = eventFields(cal, id, hash, metas[i]); err != nil {
+			return nil, err
+		}
+	}
+	baseFields, merged, theirFields := fields[0], fields[1], fields[2]
+
+	for key := range mergedKeys(baseFields, theirFields) {
+		changedLocally := !reflect.DeepEqual(merged[key], baseFields[key])
+		changedByThem := !reflect.DeepEqual(theirFields[key], baseFields[key])
+		if changedLocally || !changedByThem {
+			continue
+		}
+		if value, ok := theirFields[key]; ok {
+			merged[key] = value
+		} else {
+			delete(merged, key)
+		}
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var event Event
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return nil, err
+	}
+	event.Id = id
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("merged event is invalid: %w", err)
+	}
+	return &event, nil
+}
+
+// Returns the JSON fields of the event stored in the blob, decoded (decrypted) with the metadata. A zero hash is an empty event.
+func eventFields(cal *Calendar, id uuid.UUID, hash plumbing.Hash, meta Metadata) (map[string]any, error) {
+	fields := map[string]any{}
+	if hash.IsZero() {
+		return fields, nil
+	}
+	raw, err := readBlob(cal.Repository, hash)
+	if err != nil {
+		return nil, err
+	}
+	event := Event{Id: id}
+	if err := event.decode(raw, cal.EncryptionKey, meta); err != nil {
+		return nil, fmt.Errorf("failed to decode event: %w", err)
+	}
+	event.Id = uuid.Nil // the same on all sides
+
+	if raw, err = json.Marshal(event); err != nil {
+		return nil, err
+	}
+	return fields, json.Unmarshal(raw, &fields)
+}
+
+// Returns the id of the event stored in the file at the path (relative to the repo root), if it's an event file.
+func eventFileId(p string) (uuid.UUID, bool) {
+	dir, file := path.Split(p)
+	id, err := uuid.Parse(strings.TrimSuffix(file, ".json"))
+	return id, dir == EventsDirName+"/" && strings.HasSuffix(file, ".json") && err == nil
+}
+
+// Returns the metadata stored in the tree (see treeFiles), the defaults if there's none.
+func treeMetadata(repo *gogit.Repository, files map[string]plumbing.Hash) (Metadata, error) {
+	hash, ok := files[MetadataFileName]
+	if !ok {
+		return Metadata{}, nil
+	}
+	raw, err := readBlob(repo, hash)
+	if err != nil {
+		return Metadata{}, err
+	}
+	return parseMetadata(raw)
+}
+
+// Returns the union of the keys.
+func mergedKeys(a, b map[string]any) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+// Returns the best common ancestor of the commits, or zero if the histories are unrelated.
+func mergeBase(repo *gogit.Repository, a, b plumbing.Hash) (plumbing.Hash, error) {
+	commitA, err := repo.CommitObject(a)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to read commit: %w", err)
+	}
+	commitB, err := repo.CommitObject(b)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to read commit: %w", err)
+	}
+	bases, err := commitA.MergeBase(commitB)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to find the merge base: %w", err)
+	}
+	if len(bases) == 0 {
+		return plumbing.ZeroHash, nil
+	}
+	return bases[0].Hash, nil
+}
+
+// Fails with ErrUncommitted if the worktree has any change, the reset of a fast-forward or of a failed merge would discard it
+// (go-git removes untracked files as well).
+func checkWorktreeClean(wt *gogit.Worktree) error {
+	status, err := wt.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	if status.IsClean() {
+		return nil
+	}
+	changed := slices.Sorted(maps.Keys(status))
+	return fmt.Errorf("%w: %s (see Core.CheckIntegrity)", ErrUncommitted, strings.Join(changed, ", "))
+}
+
+// Returns the blob hash of every file in the tree of the commit (path -> hash). A zero hash has no files.
+func treeFiles(repo *gogit.Repository, commitHash plumbing.Hash) (map[string]plumbing.Hash, error) {
+	files := map[string]plumbing.Hash{}
+	if commitHash.IsZero() {
+		return files, nil
+	}
+	commit, err := repo.CommitObject(commitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree: %w", err)
+	}
+	iter := tree.Files()
+	defer iter.Close()
+	for {
+		f, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+		files[f.Name] = f.Hash
+	}
+}
+
+func readBlob(repo *gogit.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func writeBlob(repo *gogit.Repository, hash plumbing.Hash, file billy.File) error {
+	raw, err := readBlob(repo, hash)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(raw)
+	return err
+}
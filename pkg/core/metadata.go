@@ -0,0 +1,214 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/git-calendar/core/pkg/encryption"
+	"github.com/go-git/go-billy/v5"
+)
+
+// Metadata describes how the data inside a calendar repository are stored.
+//
+// It is committed in the repository root (MetadataFileName), so that every clone knows how to read the events.
+// A missing file means the default values (deterministic encryption).
+type Metadata struct {
+	EncryptionMode  encryption.Mode `json:"encryption_mode,omitzero"`
+	PlaintextFields []string        `json:"plaintext_fields,omitzero"` // Event JSON fields that stay unencrypted in an encrypted calendar (e.g. "from", "to" for free/busy).
+	Private         bool            `json:"private,omitzero"`          // Generic commit messages and always encrypted repeat exceptions, so the git history reveals only that something changed.
+	KeySalt         string          `json:"key_salt,omitzero"`         // The salt for deriving the key from the password. Empty means the calendar name; it's set when the calendar gets renamed or duplicated.
+	KeyCheck        string          `json:"key_check,omitzero"`        // A known constant encrypted with the key, to verify the password. Empty in calendars created before it.
+}
+
+func (m Metadata) Validate() error {
+	if !m.EncryptionMode.IsValid() {
+		return fmt.Errorf("unknown encryption mode: %d", m.EncryptionMode)
+	}
+	known := eventJsonFields()
+	for _, field := range m.PlaintextFields {
+		if !slices.Contains(known, field) {
+			return fmt.Errorf("unknown event field '%s'", field)
+		}
+	}
+	return nil
+}
+
+// Reads the metadata file of a calendar repository. Returns the defaults if the file doesn't exist.
+func (c *Core) loadMetadata(name string) (Metadata, error) {
+	var meta Metadata
+
+	file, err := c.fs.Open(c.fs.Join(name, MetadataFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return meta, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	return parseMetadata(raw)
+}
+
+// Parses and validates the content of a metadata file.
+func parseMetadata(raw []byte) (Metadata, error) {
+	var meta Metadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return meta, fmt.Errorf("failed to parse metadata file: %w", err)
+	}
+	for i, field := range meta.PlaintextFields {
+		for newName, oldName := range renamedEventFields {
+			if field == oldName {
+				meta.PlaintextFields[i] = newName
+			}
+		}
+	}
+	if err := meta.Validate(); err != nil {
+		return meta, fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	return meta, nil
+}
+
+// Writes the calendar metadata into the repository and stages it. The caller is responsible for the commit.
+func (c *Core) stageMetadata(name string) error {
+	cal, ok := c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+
+	raw, err := json.MarshalIndent(cal.Metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	err = c.writeFileAtomic(c.fs.Join(name, MetadataFileName), func(file billy.File) error {
+		_, err := file.Write(raw)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+
+	w, err := cal.Repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if _, err := w.Add(MetadataFileName); err != nil {
+		return fmt.Errorf("git add: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the salt for deriving the encryption key of the calendar with the given name.
+func (m Metadata) keySalt(name string) []byte {
+	if m.KeySalt != "" {
+		return []byte(m.KeySalt)
+	}
+	return []byte(name)
+}
+
+// Reports whether the key is the one KeyCheck was made with. Always true without a KeyCheck, the caller has to verify the key another way.
+func (m Metadata) checkKey(key []byte) bool {
+	if m.KeyCheck == "" {
+		return true
+	}
+	decrypted, err := encryption.DecryptFields(m.KeyCheck, key, []byte(keyCheckAad))
+	return err == nil && decrypted == keyCheckValue
+}
+
+// Returns whether events are encoded the same way with both metadata (the key aside).
+func (m Metadata) sameEncoding(other Metadata) bool {
+	return m.EncryptionMode == other.EncryptionMode && m.Private == other.Private && slices.Equal(m.PlaintextFields, other.PlaintextFields)
+}
+
+// Returns the KeyCheck for the key.
+func newKeyCheck(key []byte) (string, error) {
+	encrypted, err := encryption.EncryptFields(keyCheckValue, key, []byte(keyCheckAad))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt key check: %w", err)
+	}
+	return encrypted.(string), nil
+}
+
+const (
+	keyCheckValue = "git-calendar"
+	keyCheckAad   = "key_check"
+)
+
+// Moves the fields which should stay in plaintext out of data and returns them.
+//
+// In private mode the repeat exceptions stay in data (get encrypted) even if "repeat" is a plaintext field,
+// because their UUIDv8 ids contain the occurrence times.
+func (m Metadata) splitPlaintext(data map[string]any) map[string]any {
+	plain := make(map[string]any)
+	fields := slices.Clone(m.PlaintextFields)
+	for _, field := range m.PlaintextFields {
+		if oldName, ok := renamedEventFields[field]; ok {
+			fields = append(fields, oldName) // older event files still have it
+		}
+	}
+	for _, field := range fields {
+		v, ok := data[field]
+		if !ok {
+			continue
+		}
+		delete(data, field)
+
+		if repeat, isMap := v.(map[string]any); isMap && field == "repeat" && m.Private {
+			if exceptions, ok := repeat["exceptions"]; ok {
+				delete(repeat, "exceptions")
+				data[field] = map[string]any{"exceptions": exceptions}
+			}
+		}
+		plain[field] = v
+	}
+	return plain
+}
+
+// Returns the commit message to use for the calendar; in private mode every message is replaced by a generic one.
+func (m Metadata) commitMessage(msg string) string {
+	if m.Private {
+		return PrivateCommitMessage
+	}
+	return msg
+}
+
+// Merges src into dst. Nested maps present in both are merged recursively, other values from src win.
+func mergeFields(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeFields(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// Event JSON fields which were renamed (new name -> old name). Older files still have the old names.
+var renamedEventFields = map[string]string{"categories": "tag"}
+
+// Returns the JSON field names of Event.
+func eventJsonFields() []string {
+	t := reflect.TypeFor[Event]()
+	fields := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
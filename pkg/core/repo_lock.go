@@ -0,0 +1,273 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Guards calendar repositories against writes of other Cores sharing the same storage
+// (other processes using the same directory, other browser tabs using the same IndexedDB store).
+// Without it, their commits interleave and corrupt the git index.
+//
+// A lease belongs to an owner (an id unique to each Core) and expires after ttl,
+// so that an owner which crashed doesn't keep the repository locked forever.
+type RepoLocker interface {
+	// Takes the lease of the calendar repository for the owner. Returns false if another owner holds it.
+	// Taking a lease the owner already holds renews it.
+	TryLease(name, owner string, ttl time.Duration) (bool, error)
+	// Gives the lease back. Does nothing if the owner doesn't hold it.
+	ReleaseLease(name, owner string) error
+}
+
+// Tells other Cores sharing the same storage that a calendar was changed, so that they can call Core.ReloadChanged.
+// Notify is called after the change is committed, while the Core is locked, so it must not block.
+type ChangeNotifier interface {
+	Notify(calendar string)
+}
+
+// Sets the notifier called after every change of a calendar. Nil disables the notifications.
+func (c *Core) SetChangeNotifier(notifier ChangeNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifier = notifier
+}
+
+// Reloads the calendars if any of them was changed outside of this Core since it was loaded
+// (by another Core sharing the storage, by a pull...). Returns the names of the changed, added and removed calendars.
+func (c *Core) ReloadChanged() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.fs.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list all directories in root: %w", err)
+	}
+
+	changed := []string{}
+	onDisk := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if _, cloning := c.clones.Load(name); cloning {
+			continue
+		}
+		onDisk[name] = true
+
+		cal, ok := c.calendars[name]
+		if !ok || repoHead(cal.Repository) != cal.head {
+			changed = append(changed, name)
+		}
+	}
+	for name := range c.calendars {
+		if !onDisk[name] {
+			changed = append(changed, name)
+		}
+	}
+
+	if len(changed) == 0 {
+		return changed, nil
+	}
+	return changed, c.loadCalendars()
+}
+
+// ------------------------------------------------ Helpers -------------------------------------------------
+
+// Takes the repository lease of the calendar, waiting up to RepoLockTimeout for another instance to release it.
+// The lease is shared by the whole Core, so the repository is also guarded by a mutex against other goroutines
+// (the network part of a sync doesn't hold the Core lock). Returns a function releasing both.
+func (c *Core) lockRepo(name string) (func(), error) {
+	return c.leaseRepo(name, c.logger)
+}
+
+// Same as lockRepo, but usable without holding the Core lock.
+func (c *Core) leaseRepo(name string, logger *slog.Logger) (func(), error) {
+	value, _ := c.repoMutexes.LoadOrStore(name, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+
+	deadline := time.Now().Add(RepoLockTimeout)
+	for {
+		if mu.TryLock() {
+			ok, err := c.locker.TryLease(name, c.instanceId, RepoLeaseTTL)
+			if err != nil {
+				mu.Unlock()
+				return nil, fmt.Errorf("failed to lock calendar repo: %w", err)
+			}
+			if ok {
+				break
+			}
+			mu.Unlock()
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("calendar '%s' is being written or synced by another instance: %w", name, ErrBusy)
+		}
+		time.Sleep(repoLockRetryDelay)
+	}
+
+	return func() {
+		defer mu.Unlock()
+		if err := c.locker.ReleaseLease(name, c.instanceId); err != nil {
+			logger.Error("failed to unlock calendar repo", "op", "unlock-repo", "calendar", name, "error", err)
+		}
+	}, nil
+}
+
+// Renews the repository lease of the calendar every third of RepoLeaseTTL, so that a long operation
+// (a push of a big history over a slow network) doesn't lose it. Returns a function stopping the renewal,
+// which has to be called before the lease is released.
+func (c *Core) keepLease(name string, logger *slog.Logger) func() {
+	ticker := time.NewTicker(RepoLeaseTTL / 3)
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if ok, err := c.locker.TryLease(name, c.instanceId, RepoLeaseTTL); err != nil || !ok {
+					logger.Error("failed to renew calendar repo lease", "op", "renew-lease", "calendar", name, "held", ok, "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		<-stopped
+	}
+}
+
+// Calls the change notifier, if there is one.
+func (c *Core) notifyChange(name string) {
+	if c.notifier != nil {
+		c.notifier.Notify(name)
+	}
+}
+
+// Returns the commit HEAD points to, or zero if there is none (yet).
+func repoHead(repo *gogit.Repository) plumbing.Hash {
+	if repo == nil {
+		return plumbing.ZeroHash
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return head.Hash()
+}
+
+const repoLockRetryDelay = 20 * time.Millisecond
+
+// A RepoLocker using lock files ("<calendar>.repo-lock" in the root), which are created exclusively,
+// so only one owner can succeed. The lease is renewed by replacing the file, it expires with its modification time.
+type fileLocker struct {
+	fs billy.Filesystem
+}
+
+func (l fileLocker) TryLease(name, owner string, ttl time.Duration) (bool, error) {
+	lockPath := repoLockFileName(name)
+
+	for range 2 { // the second attempt follows a release in between
+		file, err := l.fs.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = file.Write([]byte(owner))
+			if err = errors.Join(err, file.Close()); err != nil {
+				_ = l.fs.Remove(lockPath)
+				return false, fmt.Errorf("failed to write lock file: %w", err)
+			}
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return false, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		holder, err := readWholeFile(l.fs, lockPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // released meanwhile
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read lock file: %w", err)
+		}
+		if string(holder) == owner {
+			return true, l.write(lockPath, owner)
+		}
+
+		info, err := l.fs.Stat(lockPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to stat lock file: %w", err)
+		}
+		if time.Since(info.ModTime()) < ttl {
+			return false, nil // held by someone else
+		}
+
+		// take over the expired lease: the file is replaced (never removed), so if more owners do it at once,
+		// only the last one finds itself in it
+		if err := l.write(lockPath, owner); err != nil {
+			return false, err
+		}
+		holder, err = readWholeFile(l.fs, lockPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("failed to read lock file: %w", err)
+		}
+		return string(holder) == owner, nil
+	}
+	return false, nil
+}
+
+func (l fileLocker) ReleaseLease(name, owner string) error {
+	lockPath := repoLockFileName(name)
+
+	holder, err := readWholeFile(l.fs, lockPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read lock file: %w", err)
+	}
+	if string(holder) != owner {
+		return nil // not ours (anymore)
+	}
+	if err := l.fs.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// Atomically replaces the lock file with one owned by the owner, which also moves its modification time
+// (and so the expiration) forward. Readers never see it empty.
+func (l fileLocker) write(lockPath, owner string) error {
+	tmpPath := fmt.Sprintf("%s.%s.tmp", lockPath, owner)
+	file, err := l.fs.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	_, err = file.Write([]byte(owner))
+	if err = errors.Join(err, file.Close()); err == nil {
+		err = l.fs.Rename(tmpPath, lockPath)
+	}
+	if err != nil {
+		_ = l.fs.Remove(tmpPath)
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+// Returns the name of the lock file guarding writes into the calendar repository.
+func repoLockFileName(name string) string {
+	return fmt.Sprintf("%s.repo-lock", name)
+}
@@ -0,0 +1,71 @@
+//go:build !js
+
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const sshSupported = true
+
+// Converts the credentials into the auth of an ssh remote: the private key (decrypted by the password, if it's encrypted)
+// and the known hosts verifying the server.
+func sshAuth(remoteUrl *url.URL, creds *Credentials) (transport.AuthMethod, error) {
+	if creds.PrivateKey == "" {
+		return nil, fmt.Errorf("%w: ssh remote needs a private key", ErrAuthFailed)
+	}
+	user := cmp.Or(remoteUrl.User.Username(), creds.Username, ssh.DefaultUsername)
+
+	auth, err := ssh.NewPublicKeys(user, []byte(creds.PrivateKey), creds.Password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ssh private key: %w", ErrAuthFailed, err)
+	}
+	if auth.HostKeyCallback, err = knownHostsCallback(creds.KnownHosts); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// Returns the host key check with the known hosts lines, or with the user's known_hosts files if empty.
+func knownHostsCallback(knownHosts string) (gossh.HostKeyCallback, error) {
+	if knownHosts == "" {
+		callback, err := ssh.NewKnownHostsCallback() // ~/.ssh/known_hosts, /etc/ssh/ssh_known_hosts or $SSH_KNOWN_HOSTS
+		if err != nil {
+			return nil, fmt.Errorf("no known hosts to verify the ssh server: %w", err)
+		}
+		return callback, nil
+	}
+
+	// the parser only reads files (eagerly, so the file can be removed right away)
+	file, err := os.CreateTemp("", "known_hosts")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create known hosts file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(knownHosts)
+	if err = errors.Join(err, file.Close()); err != nil {
+		return nil, fmt.Errorf("failed to write known hosts file: %w", err)
+	}
+
+	callback, err := ssh.NewKnownHostsCallback(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("invalid known hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// Reports whether the error is the ssh server refusing the key, or the server failing the known hosts check.
+func isSshAuthError(err error) bool {
+	var keyErr *knownhosts.KeyError
+	return errors.As(err, &keyErr) || strings.Contains(err.Error(), "ssh: unable to authenticate") // not a typed error
+}
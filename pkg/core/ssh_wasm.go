@@ -0,0 +1,19 @@
+//go:build js && wasm
+
+package core
+
+import (
+	"net/url"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+const sshSupported = false // the browser can only make http requests
+
+func sshAuth(remoteUrl *url.URL, creds *Credentials) (transport.AuthMethod, error) {
+	return nil, errSshUnsupported
+}
+
+func isSshAuthError(err error) bool {
+	return false
+}
@@ -0,0 +1,443 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/google/uuid"
+)
+
+// A set of changes across memory (events map, interval tree), the working tree and git, applied all-or-nothing.
+//
+// Every change records how to undo itself. At the end, each touched calendar gets exactly one commit.
+// If anything fails (including a commit), all the changes are undone in reverse order and
+// the branches of already committed calendars are moved back.
+type transaction struct {
+	c     *Core
+	order []string                 // touched calendars in order of first touch
+	heads map[string]plumbing.Hash // HEAD of each touched calendar before the transaction (zero if there were no commits)
+	msgs  map[string][]string      // commit message lines per calendar
+	undo  []func() error           // rollback steps, run in reverse order
+	locks []func()                 // releases the repository leases of the touched calendars
+}
+
+// Runs fn inside a transaction and commits. If fn or a commit fails, everything is rolled back.
+func (c *Core) transaction(fn func(tx *transaction) error) error {
+	tx := &transaction{
+		c:     c,
+		heads: make(map[string]plumbing.Hash),
+		msgs:  make(map[string][]string),
+	}
+	defer tx.unlock()
+
+	if err := fn(tx); err != nil {
+		return tx.rollback(err, nil)
+	}
+
+	committed := make([]string, 0, len(tx.order))
+	for _, name := range tx.order {
+		if err := c.commit(c.calendars[name], tx.commitMessage(name)); err != nil {
+			return tx.rollback(err, committed)
+		}
+		committed = append(committed, name)
+	}
+	for _, name := range committed {
+		c.notifyChange(name)
+	}
+	return nil
+}
+
+// ----------------------------------------------- Operations -----------------------------------------------
+
+// Adds a new event to memory, the index tree and the repo.
+func (tx *transaction) addEvent(event *Event, commitMsg string) error {
+	tx.putEvent(event)
+	if err := tx.indexEvent(*event); err != nil {
+		return fmt.Errorf("failed to insert into index tree: %w", err)
+	}
+	if err := tx.saveEvent(event, commitMsg); err != nil {
+		return fmt.Errorf("failed to save event to repo: %w", err)
+	}
+	return nil
+}
+
+// Replaces the stored original event by the updated one (with the same id) in memory, the index tree and the repo.
+// If the calendar changed, the file is moved from the original repo into the new one.
+func (tx *transaction) replaceEvent(original, updated *Event, commitMsg string) error {
+	if original.From != updated.From || original.getTreeEndTime() != updated.getTreeEndTime() {
+		if err := tx.unindexEvent(*original); err != nil {
+			return fmt.Errorf("failed to remove event from index tree: %w", err)
+		}
+		if err := tx.indexEvent(*updated); err != nil {
+			return fmt.Errorf("failed to reinsert event into tree: %w", err)
+		}
+	}
+	tx.putEvent(updated)
+	if err := tx.saveEvent(updated, commitMsg); err != nil {
+		return fmt.Errorf("failed to save event to repo: %w", err)
+	}
+	if original.Calendar != updated.Calendar {
+		if err := tx.deleteEvent(original, commitMsg); err != nil {
+			return fmt.Errorf("failed to delete event from the original repo: %w", err)
+		}
+	}
+	return nil
+}
+
+// Removes the stored event from memory, the index tree and the repo.
+func (tx *transaction) removeEvent(event *Event, commitMsg string) error {
+	if err := tx.unindexEvent(*event); err != nil {
+		return fmt.Errorf("failed to delete event from interval tree: %w", err)
+	}
+	tx.dropEvent(event.Id)
+	if err := tx.deleteEvent(event, commitMsg); err != nil {
+		return fmt.Errorf("failed to delete event from git: %w", err)
+	}
+	return nil
+}
+
+// Sets new metadata of a calendar and writes them into the repo.
+func (tx *transaction) saveMetadata(name string, meta Metadata, commitMsg string) error {
+	cal, ok := tx.c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+
+	original := cal.Metadata
+	cal.Metadata = meta
+	tx.onRollback(func() error {
+		cal.Metadata = original
+		return nil
+	})
+
+	if err := tx.touch(name, MetadataFileName, commitMsg); err != nil {
+		return err
+	}
+	return tx.c.stageMetadata(name)
+}
+
+// Sets a new config of a calendar and writes it into the repo.
+func (tx *transaction) saveConfig(name string, cfg CalendarConfig, commitMsg string) error {
+	cal, ok := tx.c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+
+	original := cal.Config
+	cal.Config = cfg
+	tx.onRollback(func() error {
+		cal.Config = original
+		return nil
+	})
+
+	if err := tx.touch(name, ConfigFileName, commitMsg); err != nil {
+		return err
+	}
+	return tx.c.stageConfig(name)
+}
+
+// Renames the calendar directory (with the repo), its key file and its entry in the calendars map.
+// The events are left as they are, the caller rewrites them.
+func (tx *transaction) renameCalendar(oldName, newName, commitMsg string) error {
+	cal, ok := tx.c.calendars[oldName]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", oldName, ErrNotFound)
+	}
+
+	if err := tx.c.fs.Rename(oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename repo directory: %w", err)
+	}
+	tx.onRollback(func() error {
+		return tx.c.fs.Rename(newName, oldName)
+	})
+
+	if cal.IsEncrypted() {
+		if err := tx.c.fs.Rename(keyFileName(oldName), keyFileName(newName)); err != nil {
+			return fmt.Errorf("failed to rename key file: %w", err)
+		}
+		tx.onRollback(func() error {
+			return tx.c.fs.Rename(keyFileName(newName), keyFileName(oldName))
+		})
+	}
+
+	repo, err := tx.c.initCalendarRepo(newName)
+	if err != nil {
+		return fmt.Errorf("failed to open renamed repo: %w", err)
+	}
+
+	renamed := *cal
+	renamed.Repository = repo
+	renamed.Quarantine = slices.Clone(cal.Quarantine)
+	for i := range renamed.Quarantine {
+		renamed.Quarantine[i].Calendar = newName
+	}
+	delete(tx.c.calendars, oldName)
+	tx.c.calendars[newName] = &renamed
+	tx.onRollback(func() error {
+		delete(tx.c.calendars, newName)
+		tx.c.calendars[oldName] = cal
+		return nil
+	})
+
+	if commitMsg != "" {
+		tx.msgs[newName] = append(tx.msgs[newName], commitMsg)
+	}
+	return nil
+}
+
+// ----------------------------------------------- Primitives -----------------------------------------------
+
+// Sets the event in the events map.
+func (tx *transaction) putEvent(event *Event) {
+	original, existed := tx.c.events[event.Id]
+	tx.c.events[event.Id] = event
+	tx.onRollback(func() error {
+		if existed {
+			tx.c.events[event.Id] = original
+		} else {
+			delete(tx.c.events, event.Id)
+		}
+		return nil
+	})
+}
+
+// Deletes the event from the events map.
+func (tx *transaction) dropEvent(id uuid.UUID) {
+	original, existed := tx.c.events[id]
+	if !existed {
+		return
+	}
+	delete(tx.c.events, id)
+	tx.onRollback(func() error {
+		tx.c.events[id] = original
+		return nil
+	})
+}
+
+// Inserts the event into the interval tree.
+func (tx *transaction) indexEvent(event Event) error {
+	if err := tx.c.intervalTree.InsertEvent(event); err != nil {
+		return err
+	}
+	tx.onRollback(func() error {
+		return tx.c.intervalTree.RemoveEvent(event)
+	})
+	return nil
+}
+
+// Removes the event from the interval tree.
+func (tx *transaction) unindexEvent(event Event) error {
+	if err := tx.c.intervalTree.RemoveEvent(event); err != nil {
+		return err
+	}
+	tx.onRollback(func() error {
+		return tx.c.intervalTree.InsertEvent(event)
+	})
+	return nil
+}
+
+// Writes the event file and stages it.
+func (tx *transaction) saveEvent(event *Event, commitMsg string) error {
+	if err := tx.touch(event.Calendar, eventGitPath(event.Id), commitMsg); err != nil {
+		return err
+	}
+	_, err := tx.c.stageEvent(event)
+	return err
+}
+
+// Deletes the event file and stages the removal.
+func (tx *transaction) deleteEvent(event *Event, commitMsg string) error {
+	if err := tx.touch(event.Calendar, eventGitPath(event.Id), commitMsg); err != nil {
+		return err
+	}
+	_, err := tx.c.unstageEvent(event)
+	return err
+}
+
+// Deletes any file (relative to the calendar repo root) from the worktree and the git index, if it's tracked.
+func (tx *transaction) removeFile(name, relPath, commitMsg string) error {
+	if err := tx.touch(name, relPath, commitMsg); err != nil {
+		return err
+	}
+
+	w, err := tx.c.calendars[name].Repository.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := tx.c.fs.Remove(tx.c.fs.Join(name, relPath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove file from disk: %w", err)
+	}
+	if _, err := w.Remove(relPath); err != nil && !errors.Is(err, index.ErrEntryNotFound) {
+		return fmt.Errorf("git remove: %w", err)
+	}
+	return nil
+}
+
+// Registers a rollback step.
+func (tx *transaction) onRollback(step func() error) {
+	tx.undo = append(tx.undo, step)
+}
+
+// Marks the calendar as touched (remembers its HEAD) and backs up the file at relPath (relative to the repo root),
+// so that both the file and its index entry can be restored on rollback.
+func (tx *transaction) touch(name, relPath, commitMsg string) error {
+	cal, ok := tx.c.calendars[name]
+	if !ok {
+		return fmt.Errorf("calendar '%s': %w", name, ErrNotFound)
+	}
+	if cal.Repository == nil {
+		return fmt.Errorf("calendar repo not initialized")
+	}
+
+	if _, seen := tx.heads[name]; !seen {
+		unlock, err := tx.c.lockRepo(name)
+		if err != nil {
+			return err
+		}
+		tx.locks = append(tx.locks, unlock)
+
+		head, err := cal.Repository.Head()
+		switch {
+		case err == nil:
+			tx.heads[name] = head.Hash()
+			if head.Hash() != cal.head {
+				tx.c.logger.Warn("calendar changed since it was loaded, it should be reloaded", "op", "transaction", "calendar", name)
+			}
+		case errors.Is(err, plumbing.ErrReferenceNotFound):
+			tx.heads[name] = plumbing.ZeroHash // no commits yet
+		default:
+			return fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		tx.order = append(tx.order, name)
+	}
+	if commitMsg != "" {
+		tx.msgs[name] = append(tx.msgs[name], commitMsg)
+	}
+
+	fullPath := tx.c.fs.Join(name, relPath)
+	backup, readErr := readWholeFile(tx.c.fs, fullPath)
+	existed := readErr == nil
+	var entry *index.Entry // the original index entry, nil if the file wasn't in the index (e.g. untracked)
+	if idx, err := cal.Repository.Storer.Index(); err == nil {
+		if e, err := idx.Entry(relPath); err == nil {
+			copied := *e
+			entry = &copied
+		}
+	}
+
+	tx.onRollback(func() error {
+		if existed {
+			err := tx.c.writeFileAtomic(fullPath, func(file billy.File) error {
+				_, err := file.Write(backup)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		} else if err := tx.c.fs.Remove(fullPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return restoreIndexEntry(cal, relPath, entry)
+	})
+	return nil
+}
+
+// Undoes everything in reverse order and moves the branches of already committed calendars back.
+func (tx *transaction) rollback(cause error, committed []string) error {
+	errs := []error{}
+
+	for _, name := range committed {
+		if err := tx.resetHead(name); err != nil {
+			errs = append(errs, fmt.Errorf("reset '%s': %w", name, err))
+		}
+	}
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%w; rollback also failed: %v", cause, errors.Join(errs...))
+	}
+	return cause
+}
+
+// Points the current branch of the calendar back to the HEAD from before the transaction.
+func (tx *transaction) resetHead(name string) error {
+	storer := tx.c.calendars[name].Repository.Storer
+
+	head, err := storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return err
+	}
+	branch := head.Target()
+
+	tx.c.calendars[name].head = tx.heads[name]
+	if tx.heads[name].IsZero() {
+		return storer.RemoveReference(branch) // there were no commits
+	}
+	return storer.SetReference(plumbing.NewHashReference(branch, tx.heads[name]))
+}
+
+// Releases the repository leases taken by the transaction.
+func (tx *transaction) unlock() {
+	for _, unlock := range tx.locks {
+		unlock()
+	}
+}
+
+// Puts the original index entry of the file back, or removes the entry if there was none (an untracked file stays unstaged).
+func restoreIndexEntry(cal *Calendar, relPath string, entry *index.Entry) error {
+	idx, err := cal.Repository.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	if _, err := idx.Remove(relPath); err != nil && !errors.Is(err, index.ErrEntryNotFound) {
+		return fmt.Errorf("failed to remove index entry: %w", err)
+	}
+	if entry != nil {
+		idx.Entries = append(idx.Entries, entry)
+	}
+	if err := cal.Repository.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// Returns the commit message for the calendar. Multiple changes get a summary line with the list of changes.
+func (tx *transaction) commitMessage(name string) string {
+	msgs := tx.msgs[name]
+	switch len(msgs) {
+	case 0:
+		return DefaultCommitMessage
+	case 1:
+		return msgs[0]
+	default:
+		return fmt.Sprintf("%d changes\n\n- %s", len(msgs), strings.Join(msgs, "\n- "))
+	}
+}
+
+// Returns the path of an event file relative to the repo root (with forward slashes, as git wants).
+func eventGitPath(id uuid.UUID) string {
+	return filepath.ToSlash(filepath.Join(EventsDirName, fmt.Sprintf("%s.json", id)))
+}
+
+// Reads the whole file.
+func readWholeFile(fs billy.Filesystem, filePath string) ([]byte, error) {
+	file, err := fs.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
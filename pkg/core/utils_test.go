@@ -117,6 +117,13 @@ func TestPrepareRepoUrl(t *testing.T) {
 			urlWant:  mustParseUrl("https://cors-proxy.abc/https://github.com/joe/my-calendar"),
 			authWant: &http.BasicAuth{Username: "joe", Password: "1234"},
 		},
+		{
+			name:     "ssh ignores proxy",
+			repoUrl:  mustParseUrl("ssh://github.com/joe/my-calendar.git"),
+			proxyUrl: someProxyUrl,
+			urlWant:  mustParseUrl("ssh://github.com/joe/my-calendar.git"),
+			authWant: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -290,3 +297,24 @@ func mustParseUrl(raw string) *url.URL {
 	}
 	return u
 }
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   Progress
+		wantOk bool
+	}{
+		{line: "Counting objects:  45% (9/20)", want: Progress{Stage: "Counting objects", Current: 9, Total: 20}, wantOk: true},
+		{line: "remote: Compressing objects: 100% (3/3), done.", want: Progress{Stage: "Compressing objects", Current: 3, Total: 3}, wantOk: true},
+		{line: "Enumerating objects: 5, done.", wantOk: false},
+		{line: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseProgress(tt.line)
+			if ok != tt.wantOk || !cmp.Equal(tt.want, got) {
+				t.Errorf("parseProgress() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -12,18 +12,26 @@ import (
 // DecryptField accepts a map[string]any, []any or pure string and returns a the same type with decrypted values using the key+aad.
 // The encrypted text is expected to be base64 encoded. It works recursively.
 func DecryptFields(v any, key, aad []byte) (any, error) {
+	return DecryptFieldsWithMode(v, key, aad, Deterministic)
+}
+
+// DecryptFieldsWithMode is the same as DecryptFields, but for values encrypted with the specified mode.
+func DecryptFieldsWithMode(v any, key, aad []byte, mode Mode) (any, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("invalid encryption mode: %d", mode)
+	}
 	siv, err := aessiv.New(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create aes instance: %w", err)
 	}
-	dec, err := decryptAll(v, aad, siv)
+	dec, err := decryptAll(v, aad, siv, mode)
 	if err != nil {
 		return nil, err
 	}
 	return dec, nil
 }
 
-func decryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
+func decryptAll(v any, aad []byte, siv *aessiv.AESSIV, mode Mode) (any, error) {
 	switch val := v.(type) {
 
 	case map[string]any:
@@ -31,7 +39,7 @@ func decryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
 		out := make(map[string]any)
 		for k, nestedVal := range val {
 			nestedAD := appendPath(aad, k) // uuid|...|fieldname
-			dv, err := decryptAll(nestedVal, nestedAD, siv)
+			dv, err := decryptAll(nestedVal, nestedAD, siv, mode)
 			if err != nil {
 				return nil, err
 			}
@@ -43,7 +51,7 @@ func decryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
 		// recursively for arrays/slices
 		for i, nestedVal := range val {
 			nestedAD := appendPath(aad, strconv.Itoa(i)) // uuid|...|fieldname|i
-			dv, err := decryptAll(nestedVal, nestedAD, siv)
+			dv, err := decryptAll(nestedVal, nestedAD, siv, mode)
 			if err != nil {
 				return nil, err
 			}
@@ -53,7 +61,7 @@ func decryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
 
 	case string:
 		// leaf value
-		pt, err := decryptValue(val, aad, siv)
+		pt, err := decryptValue(val, aad, siv, mode)
 		if err != nil {
 			return nil, err
 		}
@@ -71,10 +79,18 @@ func decryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
 	}
 }
 
-func decryptValue(ciphertext string, aad []byte, siv *aessiv.AESSIV) ([]byte, error) {
+func decryptValue(ciphertext string, aad []byte, siv *aessiv.AESSIV, mode Mode) ([]byte, error) {
 	ct, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return nil, fmt.Errorf("invalid base64 value: %w", err)
 	}
-	return siv.Open(nil, nil, ct, aad)
+
+	var nonce []byte = nil
+	if mode == Randomized { // split the prepended nonce
+		if len(ct) < NonceSize {
+			return nil, fmt.Errorf("ciphertext too short for nonce")
+		}
+		nonce, ct = ct[:NonceSize], ct[NonceSize:]
+	}
+	return siv.Open(nil, nonce, ct, aad)
 }
@@ -7,9 +7,13 @@
 //
 // Decryption performs the reverse operation, restoring the original data
 // structure and value types via JSON unmarshaling.
+//
+// By default the encryption is deterministic (no nonce). The Randomized mode
+// prepends a random nonce to every value, hiding equality patterns.
 package encryption
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -20,14 +24,22 @@ import (
 
 // EncryptFields accepts anything and returns a new any with values encrypted using key+add and base64 encoded. It works recursively.
 func EncryptFields(v any, key, aad []byte) (any, error) {
+	return EncryptFieldsWithMode(v, key, aad, Deterministic)
+}
+
+// EncryptFieldsWithMode is the same as EncryptFields, but lets the caller choose the encryption mode.
+func EncryptFieldsWithMode(v any, key, aad []byte, mode Mode) (any, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("invalid encryption mode: %d", mode)
+	}
 	siv, err := aessiv.New(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create aes instance: %w", err)
 	}
-	return encryptAll(v, aad, siv)
+	return encryptAll(v, aad, siv, mode)
 }
 
-func encryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
+func encryptAll(v any, aad []byte, siv *aessiv.AESSIV, mode Mode) (any, error) {
 	switch val := v.(type) {
 
 	case map[string]any:
@@ -35,7 +47,7 @@ func encryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
 		out := make(map[string]any)
 		for k, nestedVal := range val {
 			nestedAD := appendPath(aad, k) // uuid|...|fieldname
-			ev, err := encryptAll(nestedVal, nestedAD, siv)
+			ev, err := encryptAll(nestedVal, nestedAD, siv, mode)
 			if err != nil {
 				return nil, err
 			}
@@ -47,7 +59,7 @@ func encryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
 		// recursively for arrays/slices
 		for i, nestedVal := range val {
 			nestedAD := appendPath(aad, strconv.Itoa(i)) // uuid|...|fieldname|i
-			ev, err := encryptAll(nestedVal, nestedAD, siv)
+			ev, err := encryptAll(nestedVal, nestedAD, siv, mode)
 			if err != nil {
 				return nil, err
 			}
@@ -62,11 +74,19 @@ func encryptAll(v any, aad []byte, siv *aessiv.AESSIV) (any, error) {
 			return nil, err
 		}
 
-		return encryptValue(b, aad, siv), nil
+		return encryptValue(b, aad, siv, mode)
 	}
 }
 
-func encryptValue(v []byte, aad []byte, siv *aessiv.AESSIV) string {
-	ciphertext := siv.Seal(nil, nil, v, aad)
-	return base64.StdEncoding.EncodeToString(ciphertext)
+func encryptValue(v []byte, aad []byte, siv *aessiv.AESSIV, mode Mode) (string, error) {
+	var nonce []byte = nil
+	if mode == Randomized {
+		nonce = make([]byte, NonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("failed to generate nonce: %w", err)
+		}
+	}
+
+	ciphertext := siv.Seal(nonce, nonce, v, aad) // nonce (if any) is prepended to the ciphertext
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
@@ -150,6 +150,70 @@ func TestDecryptFieldsErrors(t *testing.T) {
 	}
 }
 
+func TestEncryptFieldsModes(t *testing.T) {
+	key := DeriveKey("somepassword", []byte("salt"))
+	aad := []byte("user|123")
+	input := map[string]any{"title": "weekly sync"}
+
+	tests := []struct {
+		name      string
+		mode      Mode
+		wantEqual bool // whether encrypting the same input twice gives the same output
+	}{
+		{
+			name:      "deterministic",
+			mode:      Deterministic,
+			wantEqual: true,
+		},
+		{
+			name:      "randomized",
+			mode:      Randomized,
+			wantEqual: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := EncryptFieldsWithMode(input, key, aad, tt.mode)
+			if err != nil {
+				t.Fatalf("EncryptFieldsWithMode() error = %v", err)
+			}
+			second, err := EncryptFieldsWithMode(input, key, aad, tt.mode)
+			if err != nil {
+				t.Fatalf("EncryptFieldsWithMode() error = %v", err)
+			}
+
+			if got := reflect.DeepEqual(first, second); got != tt.wantEqual {
+				t.Errorf("equal ciphertexts = %v, want %v\nfirst:  %v\nsecond: %v", got, tt.wantEqual, first, second)
+			}
+
+			decrypted, err := DecryptFieldsWithMode(second, key, aad, tt.mode)
+			if err != nil {
+				t.Fatalf("DecryptFieldsWithMode() error = %v", err)
+			}
+			if !reflect.DeepEqual(decrypted, input) {
+				t.Fatalf("mismatch\n got: %#v\nwant: %#v", decrypted, input)
+			}
+		})
+	}
+}
+
+func TestDecryptFieldsWrongMode(t *testing.T) {
+	key := DeriveKey("somepassword", []byte("salt"))
+
+	enc, err := EncryptFieldsWithMode(map[string]any{"a": "b"}, key, nil, Randomized)
+	if err != nil {
+		t.Fatalf("EncryptFieldsWithMode() error = %v", err)
+	}
+
+	if _, err := DecryptFieldsWithMode(enc, key, nil, Deterministic); err == nil {
+		t.Fatal("expected error when decrypting randomized values as deterministic")
+	}
+	if _, err := DecryptFieldsWithMode(enc, key, nil, Mode(42)); err == nil {
+		t.Fatal("expected error for invalid mode")
+	}
+}
+
 func TestAppendPath(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,17 @@
+package encryption
+
+// Encryption mode of leaf values.
+type Mode int
+
+const (
+	Deterministic Mode = iota // Same input <=> same output (good git diffs, but equal values can be spotted across files).
+	Randomized                // A random nonce is prepended to each value, so equal values produce different ciphertexts.
+	_maxMode                  // boundary for validation
+)
+
+// Size of the random nonce used in Randomized mode.
+const NonceSize = 16
+
+func (m Mode) IsValid() bool {
+	return m >= Deterministic && m < _maxMode
+}
@@ -34,3 +34,11 @@ func GetFS() (billy.Filesystem, error) {
 	scoped := chroot.New(base, DirName)
 	return scoped, nil
 }
+
+// Returns a FS rooted at the given directory, which is created if it doesn't exist.
+func GetFSAt(rootPath string) (billy.Filesystem, error) {
+	if err := os.MkdirAll(rootPath, 0o755); err != nil {
+		return nil, err
+	}
+	return osfs.New(rootPath), nil
+}
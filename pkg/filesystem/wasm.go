@@ -13,3 +13,8 @@ const DirName = "git-calendar-data" // the storeName for IndexedDB
 func GetFS() (billy.Filesystem, error) {
 	return idb.New(DirName, 1)
 }
+
+// Returns a FS inside IndexedDB, using the given name as the storeName.
+func GetFSAt(storeName string) (billy.Filesystem, error) {
+	return idb.New(storeName, 1)
+}
@@ -188,9 +188,11 @@ func (idb *IndexedDB) Rename(oldpath, newpath string) error {
 		return err
 	}
 
-	// fail if target exists
-	if _, err := idb.Stat(newpath); err == nil {
-		return errors.New("target already exists")
+	// fail if target exists, unless both are files (then the target gets replaced, like os.Rename does)
+	if targetInfo, err := idb.Stat(newpath); err == nil {
+		if info.IsDir() || targetInfo.IsDir() {
+			return errors.New("target already exists")
+		}
 	} else if !os.IsNotExist(err) {
 		return err
 	}
@@ -379,7 +381,7 @@ func (idb *IndexedDB) renameFile(oldpath, newpath string) error {
 	if !oldInfo.Result().Truthy() {
 		return os.ErrNotExist
 	}
-	if newInfo.Result().Truthy() {
+	if newInfo.Result().Truthy() && FileInfoFromJS(newInfo.Result()).IsDir() {
 		return os.ErrExist
 	}
 
@@ -387,6 +389,7 @@ func (idb *IndexedDB) renameFile(oldpath, newpath string) error {
 	info.name = path.Base(newpath)
 	info.modTime = time.Now()
 
+	// one IDB transaction => the target is replaced atomically
 	txWrite := NewTx()
 
 	txWrite.Put(infoStoreName, newFull, info.toJS())
@@ -0,0 +1,89 @@
+//go:build js && wasm
+
+package idb
+
+import (
+	"fmt"
+	"syscall/js"
+	"time"
+)
+
+// Leases are stored in the info store under keys outside of the file tree (file keys start with "/"),
+// so they never show up as files.
+const leaseKeyPrefix = "lease:"
+
+// Takes the lease with the given name for the owner, unless another owner holds one which hasn't expired yet.
+// The check and the write run in a single IndexedDB transaction, so two browser tabs can't both get it.
+// Together with ReleaseLease it implements core.RepoLocker.
+func (idb *IndexedDB) TryLease(name, owner string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := idb.updateLease(name, func(store, current js.Value, key string) {
+		now := time.Now()
+		if current.Truthy() && current.Get("owner").String() != owner && int64(current.Get("expires").Float()) > now.UnixMilli() {
+			return // held by someone else
+		}
+		record := js.ValueOf(map[string]any{
+			"owner":   owner,
+			"expires": now.Add(ttl).UnixMilli(),
+		})
+		store.Call("put", record, key)
+		acquired = true
+	})
+	return acquired, err
+}
+
+// Gives the lease back, if the owner holds it.
+func (idb *IndexedDB) ReleaseLease(name, owner string) error {
+	return idb.updateLease(name, func(store, current js.Value, key string) {
+		if current.Truthy() && current.Get("owner").String() == owner {
+			store.Call("delete", key)
+		}
+	})
+}
+
+// Reads the lease record and lets update change it in the same readwrite transaction.
+// The update runs inside the success callback of the read, while the transaction is still active
+// (it would auto-commit before a goroutine waiting for the result could continue).
+func (idb *IndexedDB) updateLease(name string, update func(store, current js.Value, key string)) error {
+	key := leaseKeyPrefix + name
+
+	idbTx := idb.jsDB.Call("transaction", infoStoreName, "readwrite")
+	store := idbTx.Call("objectStore", infoStoreName)
+	req := store.Call("get", key)
+
+	done := make(chan error, 1)
+	var onRead, onComplete, onAbort js.Func
+	onRead = js.FuncOf(func(this js.Value, args []js.Value) any {
+		update(store, req.Get("result"), key)
+		return nil
+	})
+	onComplete = js.FuncOf(func(this js.Value, args []js.Value) any {
+		done <- nil
+		return nil
+	})
+	onAbort = js.FuncOf(func(this js.Value, args []js.Value) any {
+		// a failed request aborts the whole transaction, so this is the only place to catch errors
+		errObj := idbTx.Get("error")
+		if errObj.Truthy() {
+			done <- fmt.Errorf("IDB error: %s", errObj.String())
+		} else {
+			done <- fmt.Errorf("IDB transaction aborted")
+		}
+		return nil
+	})
+
+	req.Set("onsuccess", onRead)
+	idbTx.Set("oncomplete", onComplete)
+	idbTx.Set("onabort", onAbort)
+
+	select {
+	case err := <-done:
+		// either complete or abort fired, neither of the callbacks gets called again
+		onRead.Release()
+		onComplete.Release()
+		onAbort.Release()
+		return err
+	case <-time.After(reqTimeout):
+		return fmt.Errorf("transaction timeout after %v", reqTimeout) // the callbacks are not released, they might still fire
+	}
+}